@@ -2,9 +2,15 @@ package dnspod
 
 import (
 	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
 	"io/ioutil"
 	"net/http"
 	"net/url"
+	"strings"
+	"sync"
 	"time"
 )
 
@@ -120,19 +126,140 @@ type Status struct {
 }
 
 //----------------------------------------------------------------------------------
-func simpleHTTP(client *http.Client, method, url string, params url.Values) (res []byte, err error) {
+//# transport
+
+//client is shared by the RecordAPI, DomainAPI and UserAPI of a Dnspod instance
+type client struct {
+	credsMu    sync.RWMutex
+	creds      Credentials
+	httpClient *http.Client
+	baseURL    string
+	userAgent  string
+	timeout    time.Duration
+	retry      RetryPolicy
+
+	limit          *bucket
+	endpointLimits map[string]*bucket
+
+	middleware []Middleware
+
+	tc3 *tc3Credential //Set when calling the Tencent Cloud API 3.0 instead of dnsapi.cn
+
+	detectors map[IPVersion]IPDetector
+
+	noPreflight bool       //Set by WithPreflight(false)
+	meta        domainMeta //The grades and lines used by the preflight checks
+}
+
+//maxBodySize caps the size of a response body
+const maxBodySize = 8 << 20
+
+//post calls the specified dnspod API, such as "Record.List"
+//The errors are *HTTPError, *ResponseError or wrapped with the API name.
+func (c *client) post(ctx context.Context, action string, params url.Values) (res []byte, err error) {
+	h := c.handler()
+	attempts := c.retry.MaxAttempts
+	if attempts < 1 || (!c.retry.RetryCreate && !idempotent(action)) {
+		attempts = 1
+	}
+	for n := 1; ; n++ {
+		if err = c.waitLimit(ctx, action); err != nil {
+			return nil, fmt.Errorf("dnspod: %s: %w", action, err)
+		}
+		var resp *Response
+		resp, err = h(ctx, &Request{Endpoint: action, Params: params, Attempt: n})
+		if resp != nil {
+			res = resp.Body
+		}
+		if err == nil && !json.Valid(res) {
+			err = &ResponseError{Reason: "invalid JSON body", Snippet: snippet(res)}
+		}
+		if n >= attempts || !retryable(ctx, res, err) {
+			break
+		}
+		if err = c.retry.wait(ctx, n); err != nil {
+			return nil, fmt.Errorf("dnspod: %s: %w", action, err)
+		}
+	}
+	switch e := err.(type) {
+	case nil:
+		return res, nil
+	case *HTTPError:
+		e.Endpoint = action
+	case *ResponseError:
+		e.Endpoint = action
+	default:
+		err = fmt.Errorf("dnspod: %s: %w", action, err)
+	}
+	return nil, err
+}
+
+//send is the innermost Handler, it adds the login_token and sends the request
+func (c *client) send(ctx context.Context, req *Request) (*Response, error) {
+	if c.tc3 != nil {
+		return c.sendTC3(ctx, req)
+	}
+	params := url.Values{}
+	for k, v := range req.Params {
+		params[k] = v
+	}
+	token, err := c.loginToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+	params.Set("login_token", token)
+	params.Set("format", "json")
+	res, code, err := c.simpleHTTP(ctx, "POST", strings.TrimRight(c.baseURL, "/")+"/"+req.Endpoint, params)
+	return &Response{StatusCode: code, Body: res}, err
+}
+
+//simpleHTTP sends a form request and returns the body and the HTTP status code
+//The errors are *HTTPError, *ResponseError or the transport error.
+func (c *client) simpleHTTP(ctx context.Context, method, url string, params url.Values) (res []byte, code int, err error) {
 	body := bytes.NewBufferString(params.Encode())
 	// Create request
-	req, err := http.NewRequest(method, url, body)
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
+	if err != nil {
+		return
+	}
 	// Headers
-	req.Header.Add("User-Agent", "Go DDNS/1.0.0 (bigemon@foxmail.com)")
 	req.Header.Add("Content-Type", "application/x-www-form-urlencoded; charset=utf-8")
+	return c.doHTTP(req)
+}
+
+//doHTTP sends req with the User-Agent and timeout of the client
+func (c *client) doHTTP(req *http.Request) (res []byte, code int, err error) {
+	if c.timeout > 0 {
+		ctx, cancel := context.WithTimeout(req.Context(), c.timeout)
+		defer cancel()
+		req = req.WithContext(ctx)
+	}
+	req.Header.Set("User-Agent", c.userAgent)
 	// Fetch Request
-	resp, err := client.Do(req)
+	resp, err := c.httpClient.Do(req)
 	if err != nil {
 		return
 	}
 	defer resp.Body.Close()
-	respBody, _ := ioutil.ReadAll(resp.Body)
-	return respBody, nil
+	code = resp.StatusCode
+	respBody, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxBodySize+1))
+	if err != nil {
+		return nil, code, fmt.Errorf("reading body (truncated after %d bytes): %w", len(respBody), err)
+	}
+	if code < 200 || code > 299 {
+		return nil, code, &HTTPError{StatusCode: code, Status: resp.Status, Snippet: snippet(respBody)}
+	}
+	if len(respBody) > maxBodySize {
+		return nil, code, &ResponseError{Reason: fmt.Sprintf("body exceeds %d bytes", maxBodySize), Snippet: snippet(respBody)}
+	}
+	return respBody, code, nil
+}
+
+//snippet returns the beginning of a body for error messages
+func snippet(b []byte) string {
+	const max = 200
+	if len(b) > max {
+		return strings.TrimSpace(string(b[:max])) + "..."
+	}
+	return strings.TrimSpace(string(b))
 }
@@ -0,0 +1,209 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/bigemon/dnspod"
+)
+
+//Config is the JSON config file of dnspod-ddns
+//
+//	{
+//		"token": "ID,Token",
+//		"interval": "5m",
+//		"ip_version": "both",
+//		"detect": {
+//			"ipv4": {"quorum": 2, "sources": [
+//				{"type": "http", "url": "https://api.ipify.org"},
+//				{"type": "http", "url": "https://ipv4.icanhazip.com"},
+//				{"type": "command", "command": ["curl", "-s", "https://4.ipw.cn"]}
+//			]},
+//			"ipv6": {"sources": [{"type": "interface", "name": "eth0"}]}
+//		},
+//		"state_file": "/var/lib/dnspod-ddns/state.json",
+//		"records": [
+//			{"domain": "example.com", "sub_domain": "home"}
+//		]
+//	}
+type Config struct {
+	Token     string   `json:"token"`      //"ID,Token", DNSPOD_TOKEN/DNSPOD_TOKEN_ID are used when empty
+	TokenFile string   `json:"token_file"` //A file containing "ID,Token", reloaded when modified
+	Interval  Duration `json:"interval"`   //The polling interval, 5m by default
+	StateFile string   `json:"state_file"` //Where the last known IPs are saved, dnspod-ddns.state.json by default
+	IPVersion string   `json:"ip_version"` //"ipv4" (A records, default), "ipv6" (AAAA records) or "both"
+	//Detect sets how the WAN IP is detected, keyed by "ipv4"/"ipv6", dnspod.DefaultIPDetector is used when absent
+	Detect  map[string]DetectConfig `json:"detect"`
+	Records []Target                `json:"records"`
+}
+
+//DetectConfig is the sources of the WAN IP, an IP is accepted when Quorum sources agree
+type DetectConfig struct {
+	Quorum  int      `json:"quorum"` //1 by default
+	Sources []Source `json:"sources"`
+}
+
+//Source is a source of the WAN IP
+type Source struct {
+	Type    string   `json:"type"`    //"http", "interface" or "command"
+	URL     string   `json:"url"`     //The echo service of "http"
+	Name    string   `json:"name"`    //The interface of "interface", all interfaces when empty
+	Command []string `json:"command"` //The command and args of "command"
+}
+
+//detectTimeout bounds each HTTP source, so a hung echo service is counted as a failed vote
+const detectTimeout = 10 * time.Second
+
+//detector builds the IPDetector of the config
+func (c DetectConfig) detector(version dnspod.IPVersion) (dnspod.IPDetector, error) {
+	q := &dnspod.QuorumDetector{Quorum: c.Quorum}
+	hc := &http.Client{Timeout: detectTimeout}
+	for i, s := range c.Sources {
+		switch s.Type {
+		case "http":
+			if s.URL == "" {
+				return nil, fmt.Errorf("sources[%d]: url is required", i)
+			}
+			q.Detectors = append(q.Detectors, &dnspod.HTTPDetector{URL: s.URL, Version: version, Client: hc})
+		case "interface":
+			q.Detectors = append(q.Detectors, &dnspod.InterfaceDetector{Name: s.Name, Version: version})
+		case "command":
+			if len(s.Command) == 0 {
+				return nil, fmt.Errorf("sources[%d]: command is required", i)
+			}
+			q.Detectors = append(q.Detectors, &dnspod.CommandDetector{Command: s.Command[0], Args: s.Command[1:], Version: version})
+		default:
+			return nil, fmt.Errorf("sources[%d]: invalid type %q", i, s.Type)
+		}
+	}
+	if len(q.Detectors) == 0 {
+		return nil, errors.New("no sources")
+	}
+	if q.Quorum > len(q.Detectors) {
+		return nil, fmt.Errorf("quorum %d exceeds the %d sources", q.Quorum, len(q.Detectors))
+	}
+	return q, nil
+}
+
+//detectors builds the IPDetector options of the config
+func (c Config) detectors() (opt []dnspod.Option, err error) {
+	for key, dc := range c.Detect {
+		var version dnspod.IPVersion
+		switch key {
+		case "ipv4":
+			version = dnspod.IPv4
+		case "ipv6":
+			version = dnspod.IPv6
+		default:
+			return nil, fmt.Errorf("detect: invalid key %q", key)
+		}
+		d, err := dc.detector(version)
+		if err != nil {
+			return nil, fmt.Errorf("detect.%s: %w", key, err)
+		}
+		opt = append(opt, dnspod.WithIPDetector(version, d))
+	}
+	return opt, nil
+}
+
+//Target is a record kept up to date
+type Target struct {
+	Domain     string            `json:"domain"`
+	SubDomain  string            `json:"sub_domain"`  //"@" by default
+	RecordID   int64             `json:"record_id"`   //Looked up by sub_domain when 0
+	RecordLine dnspod.RecordLine `json:"record_line"` //"默认" by default
+	IPVersion  string            `json:"ip_version"`  //Overrides Config.IPVersion
+}
+
+//Types returns the record types to update
+func (t Target) Types() []dnspod.RType {
+	switch t.IPVersion {
+	case "ipv6":
+		return []dnspod.RType{dnspod.RTypeAAAA}
+	case "both":
+		return []dnspod.RType{dnspod.RTypeA, dnspod.RTypeAAAA}
+	}
+	return []dnspod.RType{dnspod.RTypeA}
+}
+
+//Key identifies a record type of the target in the state file
+func (t Target) Key(rtype dnspod.RType) string {
+	key := t.SubDomain + "." + t.Domain + "/" + string(t.RecordLine)
+	if rtype != dnspod.RTypeA {
+		key += "/" + string(rtype)
+	}
+	return key
+}
+
+//Duration is a time.Duration read from strings like "5m"
+type Duration time.Duration
+
+//UnmarshalJSON json interface
+func (p *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	d, err := time.ParseDuration(s)
+	*p = Duration(d)
+	return err
+}
+
+func loadConfig(path string) (cfg Config, err error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return
+	}
+	if err = json.Unmarshal(b, &cfg); err != nil {
+		return cfg, fmt.Errorf("%s: %w", path, err)
+	}
+	if len(cfg.Records) == 0 {
+		return cfg, errors.New("no records in " + path)
+	}
+	if cfg.Interval <= 0 {
+		cfg.Interval = Duration(5 * time.Minute)
+	}
+	if err = checkIPVersion(cfg.IPVersion); err != nil {
+		return cfg, err
+	}
+	if _, err = cfg.detectors(); err != nil {
+		return cfg, err
+	}
+	if cfg.StateFile == "" {
+		cfg.StateFile = "dnspod-ddns.state.json"
+	}
+	for i := range cfg.Records {
+		t := &cfg.Records[i]
+		if t.Domain == "" {
+			return cfg, fmt.Errorf("records[%d]: domain is required", i)
+		}
+		if t.SubDomain == "" {
+			t.SubDomain = "@"
+		}
+		if t.RecordLine == "" {
+			t.RecordLine = dnspod.LineDefault
+		}
+		if t.IPVersion == "" {
+			t.IPVersion = cfg.IPVersion
+		}
+		if err = checkIPVersion(t.IPVersion); err != nil {
+			return cfg, fmt.Errorf("records[%d]: %w", i, err)
+		}
+		if t.RecordID != 0 && t.IPVersion == "both" {
+			return cfg, fmt.Errorf("records[%d]: record_id can not be used with ip_version both", i)
+		}
+	}
+	return cfg, nil
+}
+
+func checkIPVersion(v string) error {
+	switch v {
+	case "", "ipv4", "ipv6", "both":
+		return nil
+	}
+	return fmt.Errorf("invalid ip_version %q", v)
+}
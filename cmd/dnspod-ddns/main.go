@@ -0,0 +1,213 @@
+//Command dnspod-ddns keeps dnspod records pointing to the WAN IP of this host
+//
+//	dnspod-ddns -config dnspod-ddns.json
+//
+//It polls the WAN IP on an interval and calls Record.Ddns only when the IP changes,
+//on the first run (or without the state file) the records already pointing to the IP are left alone.
+//AAAA records are kept up to date with the WAN IPv6 address when ip_version is "ipv6" or "both".
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/bigemon/dnspod"
+)
+
+func main() {
+	configPath := flag.String("config", "dnspod-ddns.json", "path of the config file")
+	once := flag.Bool("once", false, "update once and exit")
+	flag.Parse()
+
+	cfg, err := loadConfig(*configPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	d, err := newDnspod(cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+	state, err := loadState(cfg.StateFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+	u := &updater{d: d, cfg: cfg, state: state}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+	if *once {
+		if err := u.runOnce(ctx); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	t := time.NewTicker(time.Duration(cfg.Interval))
+	defer t.Stop()
+	for {
+		if err := u.runOnce(ctx); err != nil {
+			log.Print(err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
+
+func newDnspod(cfg Config) (*dnspod.Dnspod, error) {
+	opt, err := cfg.detectors()
+	if err != nil {
+		return nil, err
+	}
+	opt = append(opt, dnspod.WithTimeout(30*time.Second))
+	switch {
+	case cfg.Token != "":
+		creds, err := dnspod.NewStaticToken(cfg.Token)
+		if err != nil {
+			return nil, err
+		}
+		opt = append(opt, dnspod.WithCredentials(creds))
+	case cfg.TokenFile != "":
+		creds, err := dnspod.NewFileCredentials(cfg.TokenFile)
+		if err != nil {
+			return nil, err
+		}
+		opt = append(opt, dnspod.WithCredentials(creds))
+	default:
+		return dnspod.NewDnspodFromEnv(opt...)
+	}
+	return dnspod.NewDnspod("", opt...), nil
+}
+
+//updater updates the targets when the WAN IP changes
+type updater struct {
+	d     *dnspod.Dnspod
+	cfg   Config
+	state State
+}
+
+//runOnce is run bounded by the interval, so a hung call does not block the next runs
+func (u *updater) runOnce(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(u.cfg.Interval))
+	defer cancel()
+	return u.run(ctx)
+}
+
+//run checks the WAN IP once and updates the targets pointing to another IP
+func (u *updater) run(ctx context.Context) error {
+	ips := map[dnspod.RType]string{}
+	for _, t := range u.cfg.Records {
+		for _, rtype := range t.Types() {
+			if _, ok := ips[rtype]; ok {
+				continue
+			}
+			ip, err := u.wanIP(ctx, rtype)
+			if err != nil {
+				//the targets of this type are skipped
+				log.Printf("get WAN IP for %s records: %v", rtype, err)
+			}
+			ips[rtype] = ip
+		}
+	}
+	changed := false
+	for _, t := range u.cfg.Records {
+		for _, rtype := range t.Types() {
+			ip := ips[rtype]
+			key := t.Key(rtype)
+			e := u.state[key]
+			if ip == "" || e.IP == ip {
+				continue
+			}
+			id := e.RecordID
+			if e.IP == "" {
+				//first run or lost state, the record may already point to ip
+				r, err := u.record(ctx, t, rtype, id)
+				if err != nil {
+					log.Printf("%s: %v", key, err)
+					continue
+				}
+				if net.ParseIP(r.Value).Equal(net.ParseIP(ip)) {
+					u.state[key] = Entry{IP: ip, RecordID: r.ID, UpdatedAt: time.Now()}
+					changed = true
+					continue
+				}
+				id = r.ID
+			}
+			id, err := u.update(ctx, t, rtype, id, ip)
+			if errors.Is(err, dnspod.ErrRecordNotFound) && e.RecordID != 0 {
+				//the saved record was removed, look it up again
+				id, err = u.update(ctx, t, rtype, 0, ip)
+			}
+			if err != nil {
+				log.Printf("%s: %v", key, err)
+				continue
+			}
+			log.Printf("%s: %s => %s", key, e.IP, ip)
+			u.state[key] = Entry{IP: ip, RecordID: id, UpdatedAt: time.Now()}
+			changed = true
+		}
+	}
+	if !changed {
+		return nil
+	}
+	return u.state.save(u.cfg.StateFile)
+}
+
+//wanIP returns the WAN IP for the record type
+func (u *updater) wanIP(ctx context.Context, rtype dnspod.RType) (string, error) {
+	if rtype == dnspod.RTypeAAAA {
+		return u.d.MyWANIPv6Context(ctx)
+	}
+	return u.d.MyWANIPContext(ctx)
+}
+
+//record looks up the record of the target, by ID when it is known
+func (u *updater) record(ctx context.Context, t Target, rtype dnspod.RType, id int64) (dnspod.Record, error) {
+	if t.RecordID != 0 {
+		id = t.RecordID
+	}
+	if id != 0 {
+		return u.d.Record.InfoContext(ctx, t.Domain, id)
+	}
+	list, err := u.d.Record.ListContext(ctx, t.Domain, dnspod.RecordListOpt{
+		SubDomain:  t.SubDomain,
+		RecordType: rtype,
+		RecordLine: t.RecordLine,
+	})
+	if err != nil {
+		return dnspod.Record{}, err
+	}
+	if len(list.Records) == 0 {
+		return dnspod.Record{}, fmt.Errorf("no %s record found", rtype)
+	}
+	return list.Records[0], nil
+}
+
+//update points the record of the target to ip, returns the record ID
+func (u *updater) update(ctx context.Context, t Target, rtype dnspod.RType, id int64, ip string) (int64, error) {
+	if t.RecordID != 0 {
+		id = t.RecordID
+	}
+	if id == 0 {
+		r, err := u.record(ctx, t, rtype, 0)
+		if err != nil {
+			return 0, err
+		}
+		id = r.ID
+	}
+	return id, u.d.Record.DDNSContext(ctx, t.Domain, id, dnspod.DDNSOpt{
+		SubDomain:  t.SubDomain,
+		RecordLine: t.RecordLine,
+		Value:      ip,
+		RecordType: rtype,
+	})
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bigemon/dnspod"
+	"github.com/bigemon/dnspod/dnspodtest"
+)
+
+func TestUpdater(t *testing.T) {
+	s := dnspodtest.NewServer("1234,token")
+	defer s.Close()
+	s.AddDomain("example.com")
+	id := s.AddRecord("example.com", dnspod.Record{Name: "home", Type: "A", Value: "1.2.3.4", Enabled: true})
+
+	wan := "1.2.3.4"
+	calls := map[string]int{}
+	d := s.Dnspod(
+		dnspod.WithIPDetector(dnspod.IPv4, dnspod.IPDetectorFunc(func(ctx context.Context) (net.IP, error) {
+			return net.ParseIP(wan), nil
+		})),
+		dnspod.WithMiddleware(func(next dnspod.Handler) dnspod.Handler {
+			return func(ctx context.Context, req *dnspod.Request) (*dnspod.Response, error) {
+				calls[req.Endpoint]++
+				return next(ctx, req)
+			}
+		}),
+	)
+	cfg := Config{
+		Interval:  Duration(time.Minute),
+		StateFile: filepath.Join(t.TempDir(), "state.json"),
+		Records:   []Target{{Domain: "example.com", SubDomain: "home", RecordLine: dnspod.LineDefault}},
+	}
+	key := cfg.Records[0].Key(dnspod.RTypeA)
+	u := &updater{d: d, cfg: cfg, state: State{}}
+
+	//the record already points to the WAN IP, the state is seeded without Record.Ddns
+	if err := u.runOnce(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if calls["Record.Ddns"] != 0 || u.state[key].IP != wan || u.state[key].RecordID != id {
+		t.Errorf("first run: calls %v, state %+v", calls, u.state)
+	}
+	if saved, err := loadState(cfg.StateFile); err != nil || saved[key].IP != wan {
+		t.Errorf("saved state = %+v, %v", saved, err)
+	}
+
+	//the IP changes, the record is updated once
+	wan = "1.2.3.5"
+	for i := 0; i < 2; i++ {
+		if err := u.runOnce(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if calls["Record.Ddns"] != 1 || u.state[key].IP != wan {
+		t.Errorf("after a change: calls %v, state %+v", calls, u.state)
+	}
+	if r := s.Records("example.com"); r[len(r)-1].Value != wan {
+		t.Errorf("record value = %s, want %s", r[len(r)-1].Value, wan)
+	}
+
+	//a lost state file with a stale record updates it
+	u.state = State{}
+	wan = "1.2.3.6"
+	if err := u.runOnce(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if calls["Record.Ddns"] != 2 || u.state[key].IP != wan {
+		t.Errorf("lost state: calls %v, state %+v", calls, u.state)
+	}
+}
+
+func TestRunOnceTimeout(t *testing.T) {
+	d := dnspod.NewDnspod("1234,token", dnspod.WithIPDetector(dnspod.IPv4, dnspod.IPDetectorFunc(func(ctx context.Context) (net.IP, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})))
+	u := &updater{d: d, cfg: Config{
+		Interval: Duration(50 * time.Millisecond),
+		Records:  []Target{{Domain: "example.com", SubDomain: "home"}},
+	}, state: State{}}
+	done := make(chan error, 1)
+	go func() { done <- u.runOnce(context.Background()) }()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("runOnce is not bounded by the interval")
+	}
+}
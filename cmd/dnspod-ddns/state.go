@@ -0,0 +1,52 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+//State is the last known IP of each target, saved between runs
+type State map[string]Entry
+
+//Entry is the last update of a target
+type Entry struct {
+	IP        string    `json:"ip"`
+	RecordID  int64     `json:"record_id"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func loadState(path string) (State, error) {
+	s := State{}
+	b, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s, json.Unmarshal(b, &s)
+}
+
+//save writes the state atomically
+func (s State) save(path string) error {
+	b, err := json.MarshalIndent(s, "", "\t")
+	if err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err = tmp.Write(b); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
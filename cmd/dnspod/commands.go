@@ -0,0 +1,444 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/bigemon/dnspod"
+)
+
+//groups is the commands, by group and name
+var groups = map[string]map[string]command{
+	"domain": {
+		"list":   {"[-type all] [-keyword k] [-group id]", "list the domains", domainList},
+		"info":   {"<domain>", "show a domain", domainInfo},
+		"create": {"<domain> [-group id] [-mark]", "add a domain", domainCreate},
+		"remove": {"<domain>", "remove a domain", domainRemove},
+		"status": {"<domain> <enable|disable>", "enable or pause a domain", domainStatus},
+		"log":    {"<domain> [-offset n] [-length n]", "show the operation log of a domain", domainLog},
+	},
+	"record": {
+		"list":   {"<domain> [-sub name] [-type t] [-line l] [-keyword k]", "list the records of a domain", recordList},
+		"info":   {"<domain> <record-id>", "show a record", recordInfo},
+		"create": {"<domain> <type> <value> [record flags]", "add a record", recordCreate},
+		"modify": {"<domain> <record-id> <type> <value> [record flags]", "modify a record", recordModify},
+		"remove": {"<domain> <record-id>", "remove a record", recordRemove},
+		"status": {"<domain> <record-id> <enable|disable>", "enable or disable a record", recordStatus},
+		"remark": {"<domain> <record-id> <remark>", "set the remark of a record", recordRemark},
+		"ddns":   {"<domain> <record-id> [-sub name] [-line l] [-type A|AAAA] [-value ip]", "point a record to the WAN IP", recordDDNS},
+		"line":   {"<domain> [-grade g]", "list the lines available for a domain", recordLine},
+		"type":   {"<grade>", "list the record types allowed for a domain grade", recordType},
+	},
+	"user": {
+		"detail": {"", "show the account", userDetail},
+		"log":    {"", "show the operation log of the account", userLog},
+	},
+}
+
+//fieldRows is the table of a single object, a row per member of its JSON encoding
+func fieldRows(v interface{}) [][]string {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil
+	}
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	if t, err := dec.Token(); err != nil || t != json.Delim('{') {
+		return nil
+	}
+	var rows [][]string
+	for dec.More() {
+		k, err := dec.Token()
+		if err != nil {
+			break
+		}
+		var value json.RawMessage
+		if err = dec.Decode(&value); err != nil {
+			break
+		}
+		//the strings without their quotes, the other values as JSON
+		s := string(value)
+		json.Unmarshal(value, &s)
+		rows = append(rows, []string{k.(string), s})
+	}
+	return rows
+}
+
+func parseID(s string) (int64, error) {
+	id, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return 0, usageError(fmt.Sprintf("invalid record id %q", s))
+	}
+	return id, nil
+}
+
+//---------------------------------------------------------------------------------
+
+func domainList(ctx context.Context, e *env, args []string) error {
+	fs := newFlags("domain list")
+	var o dnspod.DomainListOpt
+	fs.StringVar((*string)(&o.Type), "type", "", "all, mine, share, ismark, pause, vip, recent or share_out")
+	fs.StringVar(&o.Keyword, "keyword", "", "only the domains containing the keyword")
+	fs.IntVar(&o.GroupID, "group", 0, "only the domains of the group")
+	if _, err := parseArgs(fs, args, 0); err != nil {
+		return err
+	}
+	list := []dnspod.Domain{}
+	it := e.d.Domain.IterateContext(ctx, o)
+	for it.Next() {
+		list = append(list, it.Domain())
+	}
+	if err := it.Err(); err != nil {
+		return err
+	}
+	rows := make([][]string, 0, len(list))
+	for _, d := range list {
+		rows = append(rows, []string{strconv.FormatInt(d.ID, 10), d.Name, d.Status.String(), d.Grade,
+			strconv.Itoa(d.Records), strconv.Itoa(d.TTL), d.Remark})
+	}
+	return e.out.print(list, []string{"ID", "NAME", "STATUS", "GRADE", "RECORDS", "TTL", "REMARK"}, rows)
+}
+
+func domainInfo(ctx context.Context, e *env, args []string) error {
+	pos, err := parseArgs(newFlags("domain info"), args, 1)
+	if err != nil {
+		return err
+	}
+	d, err := e.d.Domain.InfoContext(ctx, pos[0])
+	if err != nil {
+		return err
+	}
+	return e.out.print(d, nil, fieldRows(d))
+}
+
+func domainCreate(ctx context.Context, e *env, args []string) error {
+	fs := newFlags("domain create")
+	var o dnspod.DomainCreateOpt
+	fs.IntVar(&o.GroupID, "group", 0, "the group of the domain")
+	fs.BoolVar((*bool)(&o.IsMark), "mark", false, "mark the domain")
+	pos, err := parseArgs(fs, args, 1)
+	if err != nil {
+		return err
+	}
+	id, err := e.d.Domain.CreateContext(ctx, pos[0], o)
+	if err != nil {
+		return err
+	}
+	return e.out.message(map[string]interface{}{"id": id, "domain": pos[0]},
+		fmt.Sprintf("domain %s created, id %d", pos[0], id))
+}
+
+func domainRemove(ctx context.Context, e *env, args []string) error {
+	pos, err := parseArgs(newFlags("domain remove"), args, 1)
+	if err != nil {
+		return err
+	}
+	if err := e.d.Domain.RemoveContext(ctx, pos[0]); err != nil {
+		return err
+	}
+	return e.out.message(map[string]interface{}{"domain": pos[0], "removed": true},
+		fmt.Sprintf("domain %s removed", pos[0]))
+}
+
+func domainStatus(ctx context.Context, e *env, args []string) error {
+	pos, err := parseArgs(newFlags("domain status"), args, 2)
+	if err != nil {
+		return err
+	}
+	status, err := parseEnable(pos[1])
+	if err != nil {
+		return err
+	}
+	if err := e.d.Domain.StatusContext(ctx, pos[0], status); err != nil {
+		return err
+	}
+	return e.out.message(map[string]interface{}{"domain": pos[0], "status": status},
+		fmt.Sprintf("domain %s: %s", pos[0], status))
+}
+
+func domainLog(ctx context.Context, e *env, args []string) error {
+	fs := newFlags("domain log")
+	var o dnspod.DomainLogOpt
+	fs.IntVar(&o.Offset, "offset", 0, "the first entry")
+	fs.IntVar(&o.Length, "length", 0, "the number of entries, all when 0")
+	pos, err := parseArgs(fs, args, 1)
+	if err != nil {
+		return err
+	}
+	log := []string{}
+	if o.Length > 0 {
+		if log, err = e.d.Domain.LogContext(ctx, pos[0], o); err != nil {
+			return err
+		}
+	} else {
+		it := e.d.Domain.IterateLogContext(ctx, pos[0], o)
+		for it.Next() {
+			log = append(log, it.Entry())
+		}
+		if err := it.Err(); err != nil {
+			return err
+		}
+	}
+	return e.out.print(log, nil, logRows(log))
+}
+
+func logRows(log []string) [][]string {
+	rows := make([][]string, 0, len(log))
+	for _, l := range log {
+		rows = append(rows, []string{l})
+	}
+	return rows
+}
+
+//---------------------------------------------------------------------------------
+
+func recordList(ctx context.Context, e *env, args []string) error {
+	fs := newFlags("record list")
+	var o dnspod.RecordListOpt
+	fs.StringVar(&o.SubDomain, "sub", "", "only the records of the host record")
+	fs.StringVar((*string)(&o.RecordType), "type", "", "only the records of the type")
+	fs.StringVar((*string)(&o.RecordLine), "line", "", "only the records of the line")
+	fs.StringVar(&o.Keyword, "keyword", "", "only the records containing the keyword")
+	pos, err := parseArgs(fs, args, 1)
+	if err != nil {
+		return err
+	}
+	list := []dnspod.Record{}
+	it := e.d.Record.IterateContext(ctx, pos[0], o)
+	for it.Next() {
+		list = append(list, it.Record())
+	}
+	if err := it.Err(); err != nil {
+		return err
+	}
+	rows := make([][]string, 0, len(list))
+	for _, r := range list {
+		rows = append(rows, recordRow(r))
+	}
+	return e.out.print(list, []string{"ID", "NAME", "TYPE", "LINE", "VALUE", "MX", "TTL", "WEIGHT", "STATUS", "REMARK"}, rows)
+}
+
+func recordRow(r dnspod.Record) []string {
+	mx, status := "", "enable"
+	if r.Type == string(dnspod.RTypeMX) {
+		mx = strconv.Itoa(r.MX)
+	}
+	if !r.Enabled {
+		status = "disable"
+	}
+	return []string{strconv.FormatInt(r.ID, 10), r.Name, r.Type, r.Line, r.Value, mx,
+		strconv.Itoa(r.TTL), strconv.Itoa(r.Weight), status, r.Remark}
+}
+
+func recordInfo(ctx context.Context, e *env, args []string) error {
+	pos, err := parseArgs(newFlags("record info"), args, 2)
+	if err != nil {
+		return err
+	}
+	id, err := parseID(pos[1])
+	if err != nil {
+		return err
+	}
+	r, err := e.d.Record.InfoContext(ctx, pos[0], id)
+	if err != nil {
+		return err
+	}
+	return e.out.print(r, nil, fieldRows(r))
+}
+
+//recordFlags adds the flags of RecordOpt
+func recordFlags(fs *flag.FlagSet, o *dnspod.RecordOpt) {
+	fs.StringVar(&o.SubDomain, "sub", "", "host record, \"@\" by default")
+	fs.StringVar((*string)(&o.RecordLine), "line", "", "record line, \"默认\" by default")
+	fs.IntVar(&o.TTL, "ttl", 0, "TTL in seconds")
+	fs.IntVar(&o.MX, "mx", 0, "MX priority, required by MX records")
+	fs.IntVar(&o.Weight, "weight", 0, "weight 0-100, enterprise VIP domains only")
+	fs.BoolVar(&o.Disable, "disable", false, "create or set the record disabled")
+}
+
+func recordCreate(ctx context.Context, e *env, args []string) error {
+	fs := newFlags("record create")
+	var o dnspod.RecordOpt
+	recordFlags(fs, &o)
+	pos, err := parseArgs(fs, args, 3)
+	if err != nil {
+		return err
+	}
+	id, err := e.d.Record.CreateContext(ctx, pos[0], dnspod.RType(strings.ToUpper(pos[1])), pos[2], o)
+	if err != nil {
+		return err
+	}
+	return e.out.message(map[string]interface{}{"id": id, "domain": pos[0]},
+		fmt.Sprintf("record %d created", id))
+}
+
+func recordModify(ctx context.Context, e *env, args []string) error {
+	fs := newFlags("record modify")
+	var o dnspod.RecordOpt
+	recordFlags(fs, &o)
+	pos, err := parseArgs(fs, args, 4)
+	if err != nil {
+		return err
+	}
+	id, err := parseID(pos[1])
+	if err != nil {
+		return err
+	}
+	if err := e.d.Record.ModifyContext(ctx, pos[0], id, dnspod.RType(strings.ToUpper(pos[2])), pos[3], o); err != nil {
+		return err
+	}
+	return e.out.message(map[string]interface{}{"id": id, "domain": pos[0]},
+		fmt.Sprintf("record %d modified", id))
+}
+
+func recordRemove(ctx context.Context, e *env, args []string) error {
+	pos, err := parseArgs(newFlags("record remove"), args, 2)
+	if err != nil {
+		return err
+	}
+	id, err := parseID(pos[1])
+	if err != nil {
+		return err
+	}
+	if err := e.d.Record.RemoveContext(ctx, pos[0], id); err != nil {
+		return err
+	}
+	return e.out.message(map[string]interface{}{"id": id, "domain": pos[0], "removed": true},
+		fmt.Sprintf("record %d removed", id))
+}
+
+func recordStatus(ctx context.Context, e *env, args []string) error {
+	pos, err := parseArgs(newFlags("record status"), args, 3)
+	if err != nil {
+		return err
+	}
+	id, err := parseID(pos[1])
+	if err != nil {
+		return err
+	}
+	status, err := parseEnable(pos[2])
+	if err != nil {
+		return err
+	}
+	if err := e.d.Record.StatusContext(ctx, pos[0], id, status); err != nil {
+		return err
+	}
+	return e.out.message(map[string]interface{}{"id": id, "domain": pos[0], "status": status},
+		fmt.Sprintf("record %d: %s", id, status))
+}
+
+func recordRemark(ctx context.Context, e *env, args []string) error {
+	pos, err := parseArgs(newFlags("record remark"), args, 3)
+	if err != nil {
+		return err
+	}
+	id, err := parseID(pos[1])
+	if err != nil {
+		return err
+	}
+	if err := e.d.Record.RemarkContext(ctx, pos[0], id, pos[2]); err != nil {
+		return err
+	}
+	return e.out.message(map[string]interface{}{"id": id, "domain": pos[0], "remark": pos[2]},
+		fmt.Sprintf("record %d: remark set", id))
+}
+
+func recordDDNS(ctx context.Context, e *env, args []string) error {
+	fs := newFlags("record ddns")
+	var o dnspod.DDNSOpt
+	fs.StringVar(&o.SubDomain, "sub", "", "host record, \"@\" by default")
+	fs.StringVar((*string)(&o.RecordLine), "line", "", "record line, \"默认\" by default")
+	fs.StringVar((*string)(&o.RecordType), "type", "", "A (default) or AAAA")
+	fs.StringVar(&o.Value, "value", "", "the IP, the WAN IP is detected when empty")
+	pos, err := parseArgs(fs, args, 2)
+	if err != nil {
+		return err
+	}
+	id, err := parseID(pos[1])
+	if err != nil {
+		return err
+	}
+	o.RecordType = dnspod.RType(strings.ToUpper(string(o.RecordType)))
+	if o.Value == "" && o.RecordType == dnspod.RTypeAAAA {
+		//the value of the AAAA records is required
+		if o.Value, err = e.d.MyWANIPv6Context(ctx); err != nil {
+			return err
+		}
+	}
+	if err := e.d.Record.DDNSContext(ctx, pos[0], id, o); err != nil {
+		return err
+	}
+	return e.out.message(map[string]interface{}{"id": id, "domain": pos[0]},
+		fmt.Sprintf("record %d updated", id))
+}
+
+func recordLine(ctx context.Context, e *env, args []string) error {
+	fs := newFlags("record line")
+	grade := fs.String("grade", "", "the grade of the domain, looked up when empty")
+	pos, err := parseArgs(fs, args, 1)
+	if err != nil {
+		return err
+	}
+	list, err := e.d.Record.LineContext(ctx, pos[0], *grade)
+	if err != nil {
+		return err
+	}
+	type line struct {
+		Name dnspod.RecordLine `json:"name"`
+		ID   string            `json:"id"`
+	}
+	lines := make([]line, 0, len(list.Lines))
+	rows := make([][]string, 0, len(list.Lines))
+	for _, l := range list.Lines {
+		lines = append(lines, line{l, list.IDs[l]})
+		rows = append(rows, []string{string(l), list.IDs[l]})
+	}
+	return e.out.print(lines, []string{"LINE", "ID"}, rows)
+}
+
+func recordType(ctx context.Context, e *env, args []string) error {
+	pos, err := parseArgs(newFlags("record type"), args, 1)
+	if err != nil {
+		return err
+	}
+	types, err := e.d.Record.TypeContext(ctx, pos[0])
+	if err != nil {
+		return err
+	}
+	rows := make([][]string, 0, len(types))
+	for _, t := range types {
+		rows = append(rows, []string{string(t)})
+	}
+	return e.out.print(types, nil, rows)
+}
+
+//---------------------------------------------------------------------------------
+
+func userDetail(ctx context.Context, e *env, args []string) error {
+	if _, err := parseArgs(newFlags("user detail"), args, 0); err != nil {
+		return err
+	}
+	u, err := e.d.User.DetailContext(ctx)
+	if err != nil {
+		return err
+	}
+	return e.out.print(u, nil, fieldRows(u))
+}
+
+func userLog(ctx context.Context, e *env, args []string) error {
+	if _, err := parseArgs(newFlags("user log"), args, 0); err != nil {
+		return err
+	}
+	log, err := e.d.User.LogContext(ctx)
+	if err != nil {
+		return err
+	}
+	if log == nil {
+		log = []string{}
+	}
+	return e.out.print(log, nil, logRows(log))
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/bigemon/dnspod"
+)
+
+//Config is the JSON config file of dnspod, all fields are optional
+//
+//	{
+//		"token": "ID,Token",
+//		"output": "table"
+//	}
+type Config struct {
+	Token     string `json:"token"`      //"ID,Token", DNSPOD_TOKEN/DNSPOD_TOKEN_ID take precedence when set
+	TokenFile string `json:"token_file"` //A file containing "ID,Token"
+	BaseURL   string `json:"base_url"`   //dnspod.DefaultBaseURL by default, dnspod.IntlBaseURL for dnspod.com
+	Output    string `json:"output"`     //"table" (default), "json" or "yaml"
+}
+
+//defaultConfigPath is $DNSPOD_CONFIG, or dnspod/config.json in the user config directory
+func defaultConfigPath() string {
+	if p := os.Getenv("DNSPOD_CONFIG"); p != "" {
+		return p
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "dnspod", "config.json")
+}
+
+//loadConfig reads the config file, a missing file is an empty config unless required
+func loadConfig(path string, required bool) (cfg Config, err error) {
+	if path == "" {
+		return
+	}
+	b, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) && !required {
+		return cfg, nil
+	}
+	if err != nil {
+		return
+	}
+	if err = json.Unmarshal(b, &cfg); err != nil {
+		return cfg, fmt.Errorf("%s: %w", path, err)
+	}
+	return
+}
+
+//newDnspod creates the client, the token is read from the environment first, then from the config
+func newDnspod(cfg Config) (*dnspod.Dnspod, error) {
+	opt := []dnspod.Option{dnspod.WithTimeout(30 * time.Second)}
+	if cfg.BaseURL != "" {
+		opt = append(opt, dnspod.WithBaseURL(cfg.BaseURL))
+	}
+	switch {
+	case os.Getenv("DNSPOD_TOKEN") != "":
+		return dnspod.NewDnspodFromEnv(opt...)
+	case cfg.Token != "":
+		creds, err := dnspod.NewStaticToken(cfg.Token)
+		if err != nil {
+			return nil, err
+		}
+		opt = append(opt, dnspod.WithCredentials(creds))
+	case cfg.TokenFile != "":
+		creds, err := dnspod.NewFileCredentials(cfg.TokenFile)
+		if err != nil {
+			return nil, err
+		}
+		opt = append(opt, dnspod.WithCredentials(creds))
+	default:
+		return nil, fmt.Errorf("no token: set DNSPOD_TOKEN or \"token\" in the config file")
+	}
+	return dnspod.NewDnspod("", opt...), nil
+}
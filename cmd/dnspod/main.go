@@ -0,0 +1,176 @@
+//Command dnspod calls the dnspod API from the command line
+//
+//	dnspod [-config file] [-o table|json|yaml] <domain|record|user> <command> [flags] [args]
+//
+//The token is read from DNSPOD_TOKEN ("ID,Token", or "Token" with DNSPOD_TOKEN_ID),
+//then from the config file, see Config.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/bigemon/dnspod"
+)
+
+//command is a subcommand of a group, such as "record list"
+type command struct {
+	usage string //The args, after "dnspod <group> <name>"
+	help  string
+	run   func(ctx context.Context, e *env, args []string) error
+}
+
+//env is what the commands run with
+type env struct {
+	d   *dnspod.Dnspod
+	out *printer
+}
+
+//usageError is printed with the usage of the command
+type usageError string
+
+func (e usageError) Error() string {
+	return string(e)
+}
+
+func main() {
+	flag.Usage = usage
+	configPath := flag.String("config", defaultConfigPath(), "path of the config file, $DNSPOD_CONFIG")
+	output := flag.String("o", "", "output format: table, json or yaml")
+	flag.Parse()
+	args := flag.Args()
+	if len(args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+	cmds, ok := groups[args[0]]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "dnspod: unknown command %q\n", args[0])
+		usage()
+		os.Exit(2)
+	}
+	cmd, ok := cmds[args[1]]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "dnspod: unknown command %q\n", args[0]+" "+args[1])
+		usage()
+		os.Exit(2)
+	}
+
+	//an explicit -config must exist
+	explicit := false
+	flag.Visit(func(f *flag.Flag) { explicit = explicit || f.Name == "config" })
+	cfg, err := loadConfig(*configPath, explicit)
+	if err != nil {
+		fatal(err)
+	}
+	if *output != "" {
+		cfg.Output = *output
+	}
+	if cfg.Output == "" {
+		cfg.Output = "table"
+	}
+	if err := checkFormat(cfg.Output); err != nil {
+		fatal(err)
+	}
+	d, err := newDnspod(cfg)
+	if err != nil {
+		fatal(err)
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+	e := &env{d: d, out: &printer{w: os.Stdout, format: cfg.Output}}
+	err = cmd.run(ctx, e, args[2:])
+	var ue usageError
+	switch {
+	case errors.Is(err, flag.ErrHelp):
+		os.Exit(2)
+	case errors.As(err, &ue):
+		fmt.Fprintf(os.Stderr, "dnspod: %s\nusage: dnspod %s %s %s\n", ue, args[0], args[1], cmd.usage)
+		os.Exit(2)
+	case err != nil:
+		fatal(err)
+	}
+}
+
+func fatal(err error) {
+	msg := err.Error()
+	//the errors of the package are already prefixed
+	if !strings.HasPrefix(msg, "dnspod: ") {
+		msg = "dnspod: " + msg
+	}
+	fmt.Fprintln(os.Stderr, msg)
+	os.Exit(1)
+}
+
+func usage() {
+	w := flag.CommandLine.Output()
+	fmt.Fprintln(w, "usage: dnspod [-config file] [-o table|json|yaml] <group> <command> [flags] [args]")
+	fmt.Fprintln(w, "\nflags:")
+	flag.PrintDefaults()
+	var names []string
+	for g := range groups {
+		names = append(names, g)
+	}
+	sort.Strings(names)
+	for _, g := range names {
+		fmt.Fprintf(w, "\n%s commands:\n", g)
+		var cmds []string
+		for c := range groups[g] {
+			cmds = append(cmds, c)
+		}
+		sort.Strings(cmds)
+		tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+		for _, c := range cmds {
+			cmd := groups[g][c]
+			fmt.Fprintf(tw, "  %s\t%s\n", strings.TrimSpace(c+" "+cmd.usage), cmd.help)
+		}
+		tw.Flush()
+	}
+	fmt.Fprintln(w, "\nrun \"dnspod <group> <command> -h\" for the flags of a command")
+}
+
+//parseArgs parses the flags of a command, the flags may come after the positional args
+func parseArgs(fs *flag.FlagSet, args []string, want int) ([]string, error) {
+	var pos []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			break
+		}
+		pos = append(pos, args[0])
+		args = args[1:]
+	}
+	if len(pos) != want {
+		return nil, usageError(fmt.Sprintf("want %d args, got %d", want, len(pos)))
+	}
+	return pos, nil
+}
+
+//newFlags creates the flag set of a command
+func newFlags(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	return fs
+}
+
+//parseEnable parses "enable"/"disable"
+func parseEnable(s string) (dnspod.Enable, error) {
+	switch s {
+	case "enable":
+		return true, nil
+	case "disable":
+		return false, nil
+	}
+	return false, usageError(fmt.Sprintf("status is %q, want enable or disable", s))
+}
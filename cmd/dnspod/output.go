@@ -0,0 +1,204 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+//printer writes the results in the output format
+type printer struct {
+	w      io.Writer
+	format string //"table", "json" or "yaml"
+}
+
+func checkFormat(format string) error {
+	switch format {
+	case "table", "json", "yaml":
+		return nil
+	}
+	return fmt.Errorf("unknown output format %q, want table, json or yaml", format)
+}
+
+//print writes v as JSON or YAML, or header and rows as a table
+func (p *printer) print(v interface{}, header []string, rows [][]string) error {
+	switch p.format {
+	case "json":
+		enc := json.NewEncoder(p.w)
+		enc.SetIndent("", "  ")
+		enc.SetEscapeHTML(false)
+		return enc.Encode(v)
+	case "yaml":
+		return writeYAML(p.w, v)
+	}
+	tw := tabwriter.NewWriter(p.w, 0, 4, 2, ' ', 0)
+	if header != nil {
+		fmt.Fprintln(tw, strings.Join(header, "\t"))
+	}
+	for _, r := range rows {
+		fmt.Fprintln(tw, strings.Join(r, "\t"))
+	}
+	return tw.Flush()
+}
+
+//message writes the result of a command changing something, msg is the table output
+func (p *printer) message(v interface{}, msg string) error {
+	if p.format == "table" {
+		_, err := fmt.Fprintln(p.w, msg)
+		return err
+	}
+	return p.print(v, nil, nil)
+}
+
+//writeYAML writes v as a YAML document, built from its JSON encoding so that both formats show the same data
+func writeYAML(w io.Writer, v interface{}) error {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	doc, err := decodeJSON(dec)
+	if err != nil {
+		return err
+	}
+	b := &strings.Builder{}
+	yamlValue(b, doc, 0, false)
+	_, err = io.WriteString(w, b.String())
+	return err
+}
+
+//yamlPair is a member of a JSON object
+type yamlPair struct {
+	key   string
+	value interface{}
+}
+
+//decodeJSON reads a JSON value, keeping the order of the members of the objects:
+//an object is a []yamlPair, an array a []interface{}, a number a json.Number
+func decodeJSON(dec *json.Decoder) (interface{}, error) {
+	t, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch t {
+	case json.Delim('{'):
+		obj := []yamlPair{}
+		for dec.More() {
+			k, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			v, err := decodeJSON(dec)
+			if err != nil {
+				return nil, err
+			}
+			obj = append(obj, yamlPair{k.(string), v})
+		}
+		_, err = dec.Token()
+		return obj, err
+	case json.Delim('['):
+		arr := []interface{}{}
+		for dec.More() {
+			v, err := decodeJSON(dec)
+			if err != nil {
+				return nil, err
+			}
+			arr = append(arr, v)
+		}
+		_, err = dec.Token()
+		return arr, err
+	}
+	return t, nil
+}
+
+//yamlValue writes v at the indent level, inline is set when v follows a "key:" or "- " on the same line
+func yamlValue(b *strings.Builder, v interface{}, indent int, inline bool) {
+	pad := strings.Repeat("  ", indent)
+	switch v := v.(type) {
+	case []yamlPair:
+		if len(v) == 0 {
+			yamlScalar(b, "{}")
+			return
+		}
+		for i, p := range v {
+			if i > 0 || !inline {
+				b.WriteString(pad)
+			}
+			yamlEntry(b, p.key, p.value, indent)
+		}
+	case []interface{}:
+		if len(v) == 0 {
+			yamlScalar(b, "[]")
+			return
+		}
+		if inline {
+			b.WriteString("\n")
+		}
+		for _, e := range v {
+			b.WriteString(pad + "- ")
+			yamlValue(b, e, indent+1, true)
+		}
+	case string:
+		yamlScalar(b, yamlString(v))
+	case nil:
+		yamlScalar(b, "null")
+	default:
+		//json.Number and bool
+		yamlScalar(b, fmt.Sprint(v))
+	}
+}
+
+//yamlEntry writes "key: value", the nested mappings and sequences go on the next lines
+func yamlEntry(b *strings.Builder, key string, v interface{}, indent int) {
+	b.WriteString(yamlString(key) + ":")
+	switch e := v.(type) {
+	case []yamlPair:
+		if len(e) > 0 {
+			b.WriteString("\n")
+			yamlValue(b, v, indent+1, false)
+			return
+		}
+	case []interface{}:
+		if len(e) > 0 {
+			b.WriteString("\n")
+			yamlValue(b, v, indent, false)
+			return
+		}
+	}
+	b.WriteString(" ")
+	yamlValue(b, v, indent, true)
+}
+
+func yamlScalar(b *strings.Builder, s string) {
+	b.WriteString(s + "\n")
+}
+
+//yamlString quotes s when it would not be read back as the same plain string
+func yamlString(s string) string {
+	if s == "" {
+		return `""`
+	}
+	switch strings.ToLower(s) {
+	case "null", "~", "true", "false", "yes", "no", "on", "off", "y", "n":
+		return strconv.Quote(s)
+	}
+	if _, err := strconv.ParseFloat(s, 64); err == nil {
+		return strconv.Quote(s)
+	}
+	if strings.ContainsAny(s[:1], "-?:,[]{}#&*!|>'\"%@` \t") ||
+		strings.Contains(s, ": ") || strings.Contains(s, " #") ||
+		strings.HasSuffix(s, ":") || strings.HasSuffix(s, " ") {
+		return strconv.Quote(s)
+	}
+	for _, c := range s {
+		if c < ' ' || c == 0x7f {
+			return strconv.Quote(s)
+		}
+	}
+	return s
+}
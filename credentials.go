@@ -0,0 +1,221 @@
+package dnspod
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+//ErrInvalidToken the login_token is not in the "ID,Token" format
+var ErrInvalidToken = errors.New(`dnspod: login_token must be "ID,Token"`)
+
+//Credentials provides the login_token of each call, it is called before every request,
+//so an implementation can rotate the token at runtime.
+type Credentials interface {
+	LoginToken(ctx context.Context) (string, error)
+}
+
+//CredentialsFunc adapts a func to Credentials
+type CredentialsFunc func(ctx context.Context) (string, error)
+
+//LoginToken interface
+func (f CredentialsFunc) LoginToken(ctx context.Context) (string, error) {
+	return f(ctx)
+}
+
+//ValidateLoginToken checks the "ID,Token" format of a login_token
+func ValidateLoginToken(loginToken string) error {
+	i := strings.IndexByte(loginToken, ',')
+	if i <= 0 || i == len(loginToken)-1 {
+		return ErrInvalidToken
+	}
+	for _, c := range loginToken[:i] {
+		if c < '0' || c > '9' {
+			return ErrInvalidToken
+		}
+	}
+	if strings.ContainsAny(loginToken[i+1:], ", \t\r\n") {
+		return ErrInvalidToken
+	}
+	return nil
+}
+
+//JoinLoginToken joins a token ID and a token into "ID,Token"
+func JoinLoginToken(id, token string) string {
+	return strings.TrimSpace(id) + "," + strings.TrimSpace(token)
+}
+
+//StaticToken is a login_token that never changes, it can be rotated with Rotate
+type StaticToken struct {
+	mu    sync.RWMutex
+	token string
+}
+
+//NewStaticToken validates loginToken and creates a StaticToken
+func NewStaticToken(loginToken string) (*StaticToken, error) {
+	if err := ValidateLoginToken(loginToken); err != nil {
+		return nil, err
+	}
+	return &StaticToken{token: loginToken}, nil
+}
+
+//LoginToken interface
+func (p *StaticToken) LoginToken(ctx context.Context) (string, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.token, nil
+}
+
+//Rotate validates loginToken and replaces the token used by the next calls
+func (p *StaticToken) Rotate(loginToken string) error {
+	if err := ValidateLoginToken(loginToken); err != nil {
+		return err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.token = loginToken
+	return nil
+}
+
+//EnvCredentials loads the login_token from the environment variables:
+//DNSPOD_TOKEN="ID,Token", or DNSPOD_TOKEN_ID="ID" with DNSPOD_TOKEN="Token"
+func EnvCredentials() (*StaticToken, error) {
+	token := os.Getenv("DNSPOD_TOKEN")
+	if id := os.Getenv("DNSPOD_TOKEN_ID"); id != "" {
+		token = JoinLoginToken(id, token)
+	}
+	if token == "" {
+		return nil, errors.New("dnspod: DNSPOD_TOKEN is not set")
+	}
+	return NewStaticToken(token)
+}
+
+//FileCredentials loads the login_token from a file, the file is read again when it is modified.
+//The file contains "ID,Token" on the first line, or DNSPOD_TOKEN_ID=/DNSPOD_TOKEN= lines.
+//Empty lines and lines starting with # are ignored.
+type FileCredentials struct {
+	path    string
+	mu      sync.Mutex
+	modTime time.Time
+	token   string
+}
+
+//NewFileCredentials reads and validates the token file
+func NewFileCredentials(path string) (*FileCredentials, error) {
+	p := &FileCredentials{path: path}
+	if _, err := p.LoginToken(context.Background()); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+//LoginToken interface
+func (p *FileCredentials) LoginToken(ctx context.Context) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	fi, err := os.Stat(p.path)
+	if err != nil {
+		return "", err
+	}
+	if p.token != "" && fi.ModTime().Equal(p.modTime) {
+		return p.token, nil
+	}
+	b, err := ioutil.ReadFile(p.path)
+	if err != nil {
+		return "", err
+	}
+	token, err := parseTokenFile(b)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", p.path, err)
+	}
+	p.token, p.modTime = token, fi.ModTime()
+	return token, nil
+}
+
+func parseTokenFile(b []byte) (string, error) {
+	var id, token string
+	sc := bufio.NewScanner(bytes.NewReader(b))
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		k, v, ok := strings.Cut(line, "=")
+		if !ok {
+			token = line
+			break
+		}
+		v = strings.Trim(strings.TrimSpace(v), `"'`)
+		switch strings.TrimSpace(k) {
+		case "DNSPOD_TOKEN_ID":
+			id = v
+		case "DNSPOD_TOKEN":
+			token = v
+		}
+	}
+	if id != "" {
+		token = JoinLoginToken(id, token)
+	}
+	return token, ValidateLoginToken(token)
+}
+
+//WithCredentials sets the provider of the login_token, it replaces the token of NewDnspod.
+//A nil creds is ignored, so is creds with NewTencentCloud, which signs with the SecretId/SecretKey.
+func WithCredentials(creds Credentials) Option {
+	return func(c *client) {
+		c.setCredentials(creds)
+	}
+}
+
+//NewDnspodFromEnv creates a Dnspod instance with the login_token of EnvCredentials
+func NewDnspodFromEnv(opt ...Option) (*Dnspod, error) {
+	creds, err := EnvCredentials()
+	if err != nil {
+		return nil, err
+	}
+	return NewDnspod("", append([]Option{WithCredentials(creds)}, opt...)...), nil
+}
+
+//SetCredentials replaces the provider of the login_token, the next calls use the new one.
+//A nil creds is ignored, so is creds with NewTencentCloud.
+func (p *Dnspod) SetCredentials(creds Credentials) {
+	p.client.setCredentials(creds)
+}
+
+//SetLoginToken validates loginToken and uses it for the next calls,
+//it returns ErrUnsupported with NewTencentCloud
+func (p *Dnspod) SetLoginToken(loginToken string) error {
+	if p.client.tc3 != nil {
+		return fmt.Errorf("%w: the Tencent Cloud API has no login_token", ErrUnsupported)
+	}
+	creds, err := NewStaticToken(loginToken)
+	if err != nil {
+		return err
+	}
+	p.client.setCredentials(creds)
+	return nil
+}
+
+func (c *client) setCredentials(creds Credentials) {
+	if creds == nil {
+		return
+	}
+	c.credsMu.Lock()
+	defer c.credsMu.Unlock()
+	c.creds = creds
+}
+
+//loginToken returns the login_token of the next call
+func (c *client) loginToken(ctx context.Context) (string, error) {
+	c.credsMu.RLock()
+	creds := c.creds
+	c.credsMu.RUnlock()
+	return creds.LoginToken(ctx)
+}
@@ -0,0 +1,162 @@
+package dnspod
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestValidateLoginToken(t *testing.T) {
+	for token, ok := range map[string]bool{
+		"1234,abcdef":   true,
+		"1234,":         false,
+		",abcdef":       false,
+		"abcdef":        false,
+		"12a4,abcdef":   false,
+		"1234,abc,def":  false,
+		"1234,abc def":  false,
+		"1234,abcdef\n": false,
+	} {
+		if err := ValidateLoginToken(token); (err == nil) != ok {
+			t.Errorf("ValidateLoginToken(%q) = %v, want ok %v", token, err, ok)
+		}
+	}
+}
+
+func TestParseTokenFile(t *testing.T) {
+	tests := []struct {
+		file string
+		want string
+	}{
+		{"1234,abcdef\n", "1234,abcdef"},
+		{"# dnspod\n\n  1234,abcdef  \nignored\n", "1234,abcdef"},
+		{"DNSPOD_TOKEN_ID=1234\nDNSPOD_TOKEN=abcdef\n", "1234,abcdef"},
+		{"DNSPOD_TOKEN = \"abcdef\"\nDNSPOD_TOKEN_ID = '1234'\n", "1234,abcdef"},
+		{"DNSPOD_TOKEN=1234,abcdef\nOTHER=1\n", "1234,abcdef"},
+	}
+	for _, tt := range tests {
+		if got, err := parseTokenFile([]byte(tt.file)); err != nil || got != tt.want {
+			t.Errorf("parseTokenFile(%q) = %q, %v, want %q", tt.file, got, err, tt.want)
+		}
+	}
+	for _, file := range []string{"", "# only a comment\n", "DNSPOD_TOKEN_ID=1234\n", "DNSPOD_TOKEN=abcdef\n"} {
+		if _, err := parseTokenFile([]byte(file)); !errors.Is(err, ErrInvalidToken) {
+			t.Errorf("parseTokenFile(%q): err = %v, want ErrInvalidToken", file, err)
+		}
+	}
+}
+
+func TestFileCredentials(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "token")
+	write := func(token string, mtime time.Time) {
+		if err := ioutil.WriteFile(path, []byte(token), 0600); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	mtime := time.Now().Add(-time.Hour).Truncate(time.Second)
+	write("1234,first", mtime)
+	creds, err := NewFileCredentials(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	if token, _ := creds.LoginToken(ctx); token != "1234,first" {
+		t.Errorf("LoginToken = %q, want 1234,first", token)
+	}
+
+	//the file is not read again while its mtime is the same
+	write("1234,second", mtime)
+	if token, _ := creds.LoginToken(ctx); token != "1234,first" {
+		t.Errorf("LoginToken with the same mtime = %q, want 1234,first", token)
+	}
+	write("1234,second", mtime.Add(time.Minute))
+	if token, _ := creds.LoginToken(ctx); token != "1234,second" {
+		t.Errorf("LoginToken after a new mtime = %q, want 1234,second", token)
+	}
+
+	//an invalid or missing file is an error, not the old token
+	write("second", mtime.Add(2*time.Minute))
+	if _, err := creds.LoginToken(ctx); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("invalid file: err = %v, want ErrInvalidToken", err)
+	}
+	os.Remove(path)
+	if _, err := creds.LoginToken(ctx); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("missing file: err = %v, want os.ErrNotExist", err)
+	}
+	if _, err := NewFileCredentials(path); err == nil {
+		t.Error("NewFileCredentials of a missing file succeeded")
+	}
+}
+
+func TestStaticTokenRotate(t *testing.T) {
+	creds, err := NewStaticToken("1234,first")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := creds.Rotate("invalid"); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("Rotate(invalid) = %v, want ErrInvalidToken", err)
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			creds.Rotate("1234,second")
+		}()
+		go func() {
+			defer wg.Done()
+			if token, _ := creds.LoginToken(context.Background()); token != "1234,first" && token != "1234,second" {
+				t.Errorf("LoginToken = %q", token)
+			}
+		}()
+	}
+	wg.Wait()
+	if token, _ := creds.LoginToken(context.Background()); token != "1234,second" {
+		t.Errorf("LoginToken after Rotate = %q, want 1234,second", token)
+	}
+}
+
+func TestEnvCredentials(t *testing.T) {
+	t.Setenv("DNSPOD_TOKEN_ID", "")
+	t.Setenv("DNSPOD_TOKEN", "1234,abcdef")
+	if creds, err := EnvCredentials(); err != nil || creds.token != "1234,abcdef" {
+		t.Errorf("DNSPOD_TOKEN: %v", err)
+	}
+	t.Setenv("DNSPOD_TOKEN_ID", " 1234 ")
+	t.Setenv("DNSPOD_TOKEN", "abcdef")
+	if creds, err := EnvCredentials(); err != nil || creds.token != "1234,abcdef" {
+		t.Errorf("DNSPOD_TOKEN_ID: %v", err)
+	}
+	t.Setenv("DNSPOD_TOKEN", "")
+	if _, err := EnvCredentials(); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("DNSPOD_TOKEN_ID without DNSPOD_TOKEN: err = %v, want ErrInvalidToken", err)
+	}
+	t.Setenv("DNSPOD_TOKEN_ID", "")
+	if _, err := EnvCredentials(); err == nil {
+		t.Error("no environment variable: EnvCredentials succeeded")
+	}
+}
+
+func TestSetLoginToken(t *testing.T) {
+	d := NewDnspod("1234,first")
+	if err := d.SetLoginToken("second"); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("SetLoginToken(invalid) = %v, want ErrInvalidToken", err)
+	}
+	if err := d.SetLoginToken("1234,second"); err != nil {
+		t.Fatal(err)
+	}
+	if token, _ := d.client.loginToken(context.Background()); token != "1234,second" {
+		t.Errorf("login_token = %q, want 1234,second", token)
+	}
+	if err := NewTencentCloud("id", "key").SetLoginToken("1234,second"); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("SetLoginToken of NewTencentCloud = %v, want ErrUnsupported", err)
+	}
+}
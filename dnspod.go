@@ -1,46 +1,57 @@
 package dnspod
 
 import (
+	"context"
 	"net/http"
-	"regexp"
 )
 
 //NewDnspod creates and initializes a new Dnspod instance
-func NewDnspod(loginToken string) *Dnspod {
-	hc := &http.Client{}
-	R := RecordAPI{
-		loginToken: loginToken,
-		client:     hc,
-	}
-	U := UserAPI{
-		loginToken: loginToken,
-		client:     hc,
-	}
-	D := DomainAPI{
-		loginToken: loginToken,
-		client:     hc,
+//opt:			The optional settings, such as WithBaseURL/WithTimeout
+func NewDnspod(loginToken string, opt ...Option) *Dnspod {
+	return newDnspod(&client{creds: &StaticToken{token: loginToken}, baseURL: DefaultBaseURL}, opt)
+}
+
+func newDnspod(c *client, opt []Option) *Dnspod {
+	c.httpClient = &http.Client{}
+	c.userAgent = DefaultUserAgent
+	c.retry = DefaultRetryPolicy
+	for _, o := range opt {
+		o(c)
 	}
 	return &Dnspod{
-		client: hc,
-		Record: R,
-		User:   U,
-		Domain: D,
+		client: c,
+		Record: RecordAPI{c: c},
+		User:   UserAPI{c: c},
+		Domain: DomainAPI{c: c},
 	}
 }
 
 //Dnspod api 1.0
 type Dnspod struct {
-	client *http.Client
+	client *client
 	Record RecordAPI
 	User   UserAPI
 	Domain DomainAPI
 }
 
 //MyWANIP used to get your WAN IP
+//The IP is detected by DefaultIPDetector or the IPDetector set with WithIPDetector
 func (p *Dnspod) MyWANIP() (ip string, err error) {
-	res, err := simpleHTTP(p.client, "GET", "http://m.tool.chinaz.com/ipsel", nil)
-	if err != nil {
-		return
-	}
-	return string(regexp.MustCompile(`\d+\.\d+\.\d+\.\d+`).Find(res)), nil
+	return p.MyWANIPContext(context.Background())
+}
+
+//MyWANIPContext is like MyWANIP but carries ctx for cancellation and deadlines
+func (p *Dnspod) MyWANIPContext(ctx context.Context) (ip string, err error) {
+	return p.detectIP(ctx, IPv4)
+}
+
+//MyWANIPv6 used to get your WAN IPv6 address
+//The IP is detected by DefaultIPDetector or the IPDetector set with WithIPDetector
+func (p *Dnspod) MyWANIPv6() (ip string, err error) {
+	return p.MyWANIPv6Context(context.Background())
+}
+
+//MyWANIPv6Context is like MyWANIPv6 but carries ctx for cancellation and deadlines
+func (p *Dnspod) MyWANIPv6Context(ctx context.Context) (ip string, err error) {
+	return p.detectIP(ctx, IPv6)
 }
@@ -0,0 +1,863 @@
+//Package dnspodtest provides an in-memory fake of the dnspod API for tests
+//
+//	s := dnspodtest.NewServer("1234,token")
+//	defer s.Close()
+//	d := s.Dnspod()
+//	id, err := d.Domain.Create("example.com")
+package dnspodtest
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bigemon/dnspod"
+)
+
+//DefaultNS is the NS records added to a new domain
+var DefaultNS = []string{"f1g1ns1.dnspod.net.", "f1g1ns2.dnspod.net."}
+
+//DefaultLines is the record lines accepted by a new server, line name => line ID
+func DefaultLines() map[string]string {
+	return map[string]string{
+		"默认":   "0",
+		"电信":   "10=0",
+		"联通":   "10=1",
+		"教育网":  "10=2",
+		"移动":   "10=3",
+		"境外":   "3=0",
+		"搜索引擎": "80=0",
+	}
+}
+
+//DefaultTypes is the record types accepted by a new server
+func DefaultTypes() []string {
+	return []string{"A", "CNAME", "MX", "TXT", "NS", "AAAA", "SRV", "CAA", "SPF", "显性URL", "隐性URL", "HTTPS", "SVCB"}
+}
+
+func (s *Server) typesOf(grade string) []string {
+	if t, ok := s.grades[grade]; ok {
+		return t
+	}
+	return s.types
+}
+
+//Server is a fake dnspod API server keeping its state in memory
+type Server struct {
+	*httptest.Server
+	//Token is the accepted login_token
+	Token string
+
+	mu      sync.Mutex
+	nextID  int64
+	domains []*domain
+	user    dnspod.User
+	pwd     string
+	userLog []string
+	fails   map[string]dnspod.Status
+	lines   map[string]string
+	types   []string
+	grades  map[string][]string //The record types of the grades restricted by SetGradeTypes
+}
+
+type domain struct {
+	dnspod.Domain
+	records []dnspod.Record
+	log     []string
+}
+
+//NewServer starts a fake server accepting token as the login_token
+func NewServer(token string) *Server {
+	s := &Server{
+		Token:  token,
+		nextID: 1000000,
+		user: dnspod.User{
+			ID:        1234,
+			Email:     "api@example.com",
+			Nick:      "dnspodtest",
+			Status:    "ok",
+			UserGrade: "D_Free",
+		},
+		fails:  map[string]dnspod.Status{},
+		lines:  DefaultLines(),
+		types:  DefaultTypes(),
+		grades: map[string][]string{},
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+//Dnspod returns a Dnspod instance calling the server
+func (s *Server) Dnspod(opt ...dnspod.Option) *dnspod.Dnspod {
+	opt = append([]dnspod.Option{
+		dnspod.WithBaseURL(s.URL),
+		dnspod.WithHTTPClient(s.Client()),
+		dnspod.WithRetry(dnspod.RetryPolicy{}),
+	}, opt...)
+	return dnspod.NewDnspod(s.Token, opt...)
+}
+
+//SetPassword sets the password checked by Userpasswd.Modify and Useremail.Modify
+func (s *Server) SetPassword(pwd string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pwd = pwd
+}
+
+//SetLines replaces the record lines accepted by the server, line name => line ID
+func (s *Server) SetLines(lines map[string]string) {
+	m := make(map[string]string, len(lines))
+	for k, v := range lines {
+		m[k] = v
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lines = m
+}
+
+//SetTypes replaces the record types accepted by the server
+func (s *Server) SetTypes(types []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.types = append([]string(nil), types...)
+}
+
+//SetGradeTypes restricts the record types of a domain grade, the types of SetTypes are used for the other grades,
+//such as SetGradeTypes("DP_Free", []string{"A", "CNAME", "MX", "TXT", "NS"})
+func (s *Server) SetGradeTypes(grade string, types []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.grades[grade] = append([]string(nil), types...)
+}
+
+//FailNext makes the next call of the endpoint (such as "Record.Create") fail with the status
+func (s *Server) FailNext(endpoint string, code int, message string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.fails[endpoint] = dnspod.Status{Code: code, Message: message}
+}
+
+//AddDomain adds a domain with the default NS records, returns its ID
+func (s *Server) AddDomain(name string) int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.addDomain(name).ID
+}
+
+//AddRecord adds a record to a domain, returns its ID or 0 if the domain does not exist
+func (s *Server) AddRecord(domainName string, r dnspod.Record) int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d := s.findDomain(domainName)
+	if d == nil {
+		return 0
+	}
+	r.ID = s.newID()
+	if r.Line == "" {
+		r.Line = string(dnspod.LineDefault)
+	}
+	r.LineID = s.lines[r.Line]
+	if r.TTL == 0 {
+		r.TTL = 600
+	}
+	r.UpdatedOn = now()
+	d.records = append(d.records, r)
+	return r.ID
+}
+
+//Records returns a copy of the records of a domain
+func (s *Server) Records(domainName string) []dnspod.Record {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d := s.findDomain(domainName)
+	if d == nil {
+		return nil
+	}
+	return append([]dnspod.Record(nil), d.records...)
+}
+
+//----------------------------------------------------------------------------------
+
+func now() dnspod.Time {
+	return dnspod.Time(time.Now().Truncate(time.Second))
+}
+
+func (s *Server) newID() int64 {
+	s.nextID++
+	return s.nextID
+}
+
+func (s *Server) addDomain(name string) *domain {
+	d := &domain{Domain: dnspod.Domain{
+		ID:        s.newID(),
+		Status:    true,
+		Grade:     "DP_Free",
+		TTL:       600,
+		CreatedOn: now(),
+		UpdatedOn: now(),
+		Punycode:  name,
+		Name:      name,
+		Owner:     s.user.Email,
+	}}
+	for _, ns := range DefaultNS {
+		d.records = append(d.records, dnspod.Record{
+			ID:        s.newID(),
+			TTL:       86400,
+			Value:     ns,
+			Enabled:   true,
+			UpdatedOn: now(),
+			Name:      "@",
+			Line:      string(dnspod.LineDefault),
+			LineID:    "0",
+			Type:      "NS",
+		})
+	}
+	s.domains = append(s.domains, d)
+	return d
+}
+
+//findDomain looks up a domain by name or ID
+func (s *Server) findDomain(key string) *domain {
+	for _, d := range s.domains {
+		if strings.EqualFold(d.Name, key) || strconv.FormatInt(d.ID, 10) == key {
+			return d
+		}
+	}
+	return nil
+}
+
+func (d *domain) findRecord(id string) (int, *dnspod.Record) {
+	for i := range d.records {
+		if strconv.FormatInt(d.records[i].ID, 10) == id {
+			return i, &d.records[i]
+		}
+	}
+	return -1, nil
+}
+
+//apiError is a failed call, encoded as the dnspod status
+type apiError struct {
+	code    int
+	message string
+}
+
+func fail(code int, message string) *apiError {
+	return &apiError{code, message}
+}
+
+type handler func(s *Server, r *http.Request) (map[string]interface{}, *apiError)
+
+var handlers = map[string]handler{
+	"Domain.Create":        (*Server).domainCreate,
+	"Domain.List":          (*Server).domainList,
+	"Domain.Remove":        (*Server).domainRemove,
+	"Domain.Status":        (*Server).domainStatus,
+	"Domain.Info":          (*Server).domainInfo,
+	"Domain.Log":           (*Server).domainLog,
+	"Record.List":          (*Server).recordList,
+	"Record.Create":        (*Server).recordCreate,
+	"Record.Modify":        (*Server).recordModify,
+	"Record.Remove":        (*Server).recordRemove,
+	"Record.Remark":        (*Server).recordRemark,
+	"Record.Info":          (*Server).recordInfo,
+	"Record.Status":        (*Server).recordStatus,
+	"Record.Ddns":          (*Server).recordDDNS,
+	"Record.Line":          (*Server).recordLine,
+	"Record.Type":          (*Server).recordType,
+	"User.Detail":          (*Server).userDetail,
+	"User.Modify":          (*Server).userModify,
+	"Userpasswd.Modify":    (*Server).userPasswd,
+	"Useremail.Modify":     (*Server).userEmail,
+	"Telephoneverify.Code": (*Server).userVerify,
+	"User.Log":             (*Server).userLogs,
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	endpoint := strings.TrimPrefix(r.URL.Path, "/")
+	h, ok := handlers[endpoint]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	var res map[string]interface{}
+	var e *apiError
+	s.mu.Lock()
+	switch {
+	case r.Method != "POST":
+		e = fail(2, "只允许POST方法")
+	case r.ParseForm() != nil:
+		e = fail(3, "未知错误")
+	case r.PostForm.Get("login_token") != s.Token:
+		e = fail(-1, "登录失败")
+	default:
+		if st, ok := s.fails[endpoint]; ok {
+			delete(s.fails, endpoint)
+			e = fail(st.Code, st.Message)
+		} else {
+			res, e = h(s, r)
+		}
+	}
+	s.mu.Unlock()
+	if res == nil {
+		res = map[string]interface{}{}
+	}
+	st := dnspod.Status{Code: 1, Message: "Action completed successful", CreatedAt: now()}
+	if e != nil {
+		st.Code, st.Message = e.code, e.message
+	}
+	res["status"] = st
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	json.NewEncoder(w).Encode(res)
+}
+
+//----------------------------------------------------------------------------------
+//# Domain.*
+
+func (s *Server) domainCreate(r *http.Request) (map[string]interface{}, *apiError) {
+	name := strings.ToLower(r.PostForm.Get("domain"))
+	if !strings.Contains(name, ".") || strings.HasPrefix(name, ".") || strings.HasSuffix(name, ".") {
+		return nil, fail(6, "域名无效")
+	}
+	if s.findDomain(name) != nil {
+		return nil, fail(7, "域名已经存在")
+	}
+	d := s.addDomain(name)
+	if v := r.PostForm.Get("group_id"); v != "" {
+		d.GroupID, _ = strconv.Atoi(v)
+	}
+	d.IsMark = r.PostForm.Get("is_mark") == "yes"
+	s.userLog = append(s.userLog, "添加域名 "+name)
+	return map[string]interface{}{"domain": map[string]string{
+		"id":       strconv.FormatInt(d.ID, 10),
+		"punycode": d.Punycode,
+		"domain":   d.Name,
+	}}, nil
+}
+
+func (s *Server) domainList(r *http.Request) (map[string]interface{}, *apiError) {
+	f := r.PostForm
+	var list []dnspod.Domain
+	info := dnspod.DomainInfo{}
+	for _, d := range s.domains {
+		info.AllTotal++
+		info.MineTotal++
+		if d.IsMark {
+			info.IsmarkTotal++
+		}
+		if !d.Status {
+			info.PauseTotal++
+		}
+		switch dnspod.DLType(f.Get("type")) {
+		case dnspod.DLTypeShare, dnspod.DLTypeShareOut, dnspod.DLTypeVIP:
+			continue
+		case dnspod.DLTypeIsMark:
+			if !d.IsMark {
+				continue
+			}
+		case dnspod.DLTypePause:
+			if d.Status {
+				continue
+			}
+		}
+		if v := f.Get("group_id"); v != "" && v != strconv.Itoa(d.GroupID) {
+			continue
+		}
+		if v := f.Get("keyword"); v != "" && !strings.Contains(d.Name, v) {
+			continue
+		}
+		dd := d.Domain
+		dd.Records = len(d.records)
+		list = append(list, dd)
+	}
+	info.DomainTotal = len(list)
+	start, end, e := bounds(len(list), f.Get("offset"), f.Get("length"), 6)
+	if e != nil {
+		return nil, e
+	}
+	list = list[start:end]
+	if len(list) == 0 {
+		return nil, fail(9, "没有任何域名")
+	}
+	return map[string]interface{}{"info": info, "domains": list}, nil
+}
+
+//bounds applies the offset/length params to a list of n items,
+//code is the status code of an invalid offset, code+1 of an invalid length
+func bounds(n int, offset, length string, code int) (start, end int, e *apiError) {
+	end = n
+	if offset != "" {
+		v, err := strconv.Atoi(offset)
+		if err != nil || v < 0 {
+			return 0, 0, fail(code, "记录开始的偏移无效")
+		}
+		start = v
+	}
+	if length != "" {
+		v, err := strconv.Atoi(length)
+		if err != nil || v <= 0 || v > 3000 {
+			return 0, 0, fail(code+1, "共要获取的记录的数量无效")
+		}
+		end = start + v
+	}
+	if start > n {
+		start = n
+	}
+	if end > n {
+		end = n
+	}
+	return start, end, nil
+}
+
+func (s *Server) domainRemove(r *http.Request) (map[string]interface{}, *apiError) {
+	key := r.PostForm.Get("domain")
+	for i, d := range s.domains {
+		if d == s.findDomain(key) {
+			s.domains = append(s.domains[:i], s.domains[i+1:]...)
+			s.userLog = append(s.userLog, "删除域名 "+d.Name)
+			return nil, nil
+		}
+	}
+	return nil, fail(6, "域名ID错误")
+}
+
+func (s *Server) domainStatus(r *http.Request) (map[string]interface{}, *apiError) {
+	d := s.findDomain(r.PostForm.Get("domain"))
+	if d == nil {
+		return nil, fail(6, "域名ID错误")
+	}
+	switch r.PostForm.Get("status") {
+	case "enable":
+		d.Status = true
+	case "disable":
+		d.Status = false
+	default:
+		return nil, fail(3, "未知错误")
+	}
+	d.UpdatedOn = now()
+	d.log = append(d.log, "修改域名状态为 "+d.Status.String())
+	return nil, nil
+}
+
+func (s *Server) domainInfo(r *http.Request) (map[string]interface{}, *apiError) {
+	d := s.findDomain(r.PostForm.Get("domain"))
+	if d == nil {
+		return nil, fail(6, "域名ID错误")
+	}
+	dd := d.Domain
+	dd.Records = len(d.records)
+	return map[string]interface{}{"domain": dd}, nil
+}
+
+func (s *Server) domainLog(r *http.Request) (map[string]interface{}, *apiError) {
+	d := s.findDomain(r.PostForm.Get("domain"))
+	if d == nil {
+		return nil, fail(6, "域名ID错误")
+	}
+	start, end, e := bounds(len(d.log), r.PostForm.Get("offset"), r.PostForm.Get("length"), 7)
+	if e != nil {
+		return nil, e
+	}
+	return map[string]interface{}{"log": d.log[start:end]}, nil
+}
+
+//----------------------------------------------------------------------------------
+//# Record.*
+
+func (s *Server) recordList(r *http.Request) (map[string]interface{}, *apiError) {
+	f := r.PostForm
+	d := s.findDomain(f.Get("domain"))
+	if d == nil {
+		return nil, fail(6, "域名ID错误")
+	}
+	var list []dnspod.Record
+	subs := map[string]bool{}
+	for _, rec := range d.records {
+		subs[rec.Name] = true
+		if v := f.Get("sub_domain"); v != "" && !strings.EqualFold(v, rec.Name) {
+			continue
+		}
+		if v := f.Get("record_type"); v != "" && v != rec.Type {
+			continue
+		}
+		if v := f.Get("record_line"); v != "" && v != rec.Line {
+			continue
+		}
+		if v := f.Get("keyword"); v != "" && !strings.Contains(rec.Name, v) && !strings.Contains(rec.Value, v) {
+			continue
+		}
+		list = append(list, rec)
+	}
+	sort.SliceStable(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	info := dnspod.RecordInfo{SubDomains: len(subs), RecordTotal: len(d.records), RecordsNum: len(list)}
+	start, end, e := bounds(len(list), f.Get("offset"), f.Get("length"), 7)
+	if e != nil {
+		return nil, e
+	}
+	list = list[start:end]
+	if len(list) == 0 {
+		return nil, fail(10, "没有记录")
+	}
+	return map[string]interface{}{
+		"domain": dnspod.RecordDomain{
+			ID:       d.ID,
+			Name:     d.Name,
+			Punycode: d.Punycode,
+			Grade:    d.Grade,
+			Owner:    d.Owner,
+			TTL:      d.TTL,
+			MinTTL:   600,
+			DnspodNS: DefaultNS,
+			Status:   d.Status.String(),
+		},
+		"info":    info,
+		"records": list,
+	}, nil
+}
+
+//recordFromForm validates the params of Record.Create/Record.Modify of a domain of the grade into r
+func (s *Server) recordFromForm(f map[string][]string, grade string, r *dnspod.Record) *apiError {
+	get := func(k string) string {
+		if v := f[k]; len(v) > 0 {
+			return v[0]
+		}
+		return ""
+	}
+	r.Name = get("sub_domain")
+	if r.Name == "" {
+		r.Name = "@"
+	}
+	r.Type = get("record_type")
+	if !contains(s.typesOf(grade), r.Type) {
+		return fail(27, "记录类型错误")
+	}
+	r.Line = get("record_line")
+	lineID, ok := s.lines[r.Line]
+	if !ok {
+		return fail(26, "记录线路错误")
+	}
+	r.LineID = lineID
+	r.Value = get("value")
+	if e := checkValue(r.Type, r.Value); e != nil {
+		return e
+	}
+	r.MX = 0
+	if r.Type == "MX" {
+		mx, err := strconv.Atoi(get("mx"))
+		if err != nil || mx < 1 || mx > 20 {
+			return fail(30, "MX 值错误")
+		}
+		r.MX = mx
+	}
+	r.TTL = 600
+	if v := get("ttl"); v != "" {
+		ttl, err := strconv.Atoi(v)
+		if err != nil || ttl < 600 || ttl > 604800 {
+			return fail(29, "TTL 值太小")
+		}
+		r.TTL = ttl
+	}
+	r.Weight = 0
+	if v := get("weight"); v != "" {
+		r.Weight, _ = strconv.Atoi(v)
+	}
+	r.Enabled = get("status") != "disable"
+	r.UpdatedOn = now()
+	return nil
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+//checkValue validates the value of a record type
+func checkValue(rtype, value string) *apiError {
+	if value == "" {
+		return fail(34, "记录值非法")
+	}
+	ip := net.ParseIP(value)
+	switch rtype {
+	case "A":
+		if ip == nil || ip.To4() == nil {
+			return fail(34, "记录值非法")
+		}
+	case "AAAA":
+		if ip == nil || ip.To4() != nil {
+			return fail(34, "记录值非法")
+		}
+	case "CAA":
+		//flags tag "value"
+		f := strings.SplitN(value, " ", 3)
+		if len(f) != 3 || !isUint8(f[0]) || !contains([]string{"issue", "issuewild", "iodef"}, strings.ToLower(f[1])) ||
+			len(f[2]) < 2 || f[2][0] != '"' || f[2][len(f[2])-1] != '"' {
+			return fail(34, "记录值非法")
+		}
+	case "显性URL", "隐性URL":
+		u, err := url.Parse(value)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fail(34, "记录值非法")
+		}
+	case "HTTPS", "SVCB":
+		//priority target params...
+		f := strings.Fields(value)
+		if len(f) < 2 {
+			return fail(34, "记录值非法")
+		}
+		if p, err := strconv.Atoi(f[0]); err != nil || p < 0 || p > 65535 {
+			return fail(34, "记录值非法")
+		}
+	}
+	return nil
+}
+
+func isUint8(s string) bool {
+	n, err := strconv.Atoi(s)
+	return err == nil && n >= 0 && n <= 255
+}
+
+//conflict rejects a duplicated record, and a CNAME coexisting with other records of the same name and line
+func (d *domain) conflict(r *dnspod.Record) *apiError {
+	for _, v := range d.records {
+		if v.ID == r.ID || !strings.EqualFold(v.Name, r.Name) || v.Line != r.Line {
+			continue
+		}
+		if v.Type == r.Type && v.Value == r.Value {
+			return fail(104, "记录已经存在")
+		}
+		//the NS records at @ are managed by dnspod
+		if r.Name == "@" && v.Type == "NS" {
+			continue
+		}
+		if v.Type == "CNAME" || r.Type == "CNAME" {
+			return fail(104, "CNAME记录与其他记录冲突")
+		}
+	}
+	return nil
+}
+
+func (s *Server) recordCreate(r *http.Request) (map[string]interface{}, *apiError) {
+	d := s.findDomain(r.PostForm.Get("domain"))
+	if d == nil {
+		return nil, fail(6, "域名ID错误")
+	}
+	rec := dnspod.Record{}
+	if e := s.recordFromForm(r.PostForm, d.Grade, &rec); e != nil {
+		return nil, e
+	}
+	if e := d.conflict(&rec); e != nil {
+		return nil, e
+	}
+	rec.ID = s.newID()
+	d.records = append(d.records, rec)
+	d.log = append(d.log, "添加记录 "+rec.Name+" "+rec.Type+" "+rec.Value)
+	return map[string]interface{}{"record": map[string]string{
+		"id":     strconv.FormatInt(rec.ID, 10),
+		"name":   rec.Name,
+		"status": dnspod.Enable(rec.Enabled).String(),
+	}}, nil
+}
+
+func (s *Server) recordModify(r *http.Request) (map[string]interface{}, *apiError) {
+	d := s.findDomain(r.PostForm.Get("domain"))
+	if d == nil {
+		return nil, fail(6, "域名ID错误")
+	}
+	_, old := d.findRecord(r.PostForm.Get("record_id"))
+	if old == nil {
+		return nil, fail(8, "记录ID错误")
+	}
+	rec := *old
+	if e := s.recordFromForm(r.PostForm, d.Grade, &rec); e != nil {
+		return nil, e
+	}
+	if e := d.conflict(&rec); e != nil {
+		return nil, e
+	}
+	*old = rec
+	d.log = append(d.log, "修改记录 "+rec.Name+" "+rec.Type+" "+rec.Value)
+	return map[string]interface{}{"record": map[string]string{
+		"id":     strconv.FormatInt(rec.ID, 10),
+		"name":   rec.Name,
+		"value":  rec.Value,
+		"status": dnspod.Enable(rec.Enabled).String(),
+	}}, nil
+}
+
+//withRecord looks up the domain and the record_id of a request
+func (s *Server) withRecord(r *http.Request, f func(d *domain, i int, rec *dnspod.Record) (map[string]interface{}, *apiError)) (map[string]interface{}, *apiError) {
+	d := s.findDomain(r.PostForm.Get("domain"))
+	if d == nil {
+		return nil, fail(6, "域名ID错误")
+	}
+	i, rec := d.findRecord(r.PostForm.Get("record_id"))
+	if rec == nil {
+		return nil, fail(8, "记录ID错误")
+	}
+	return f(d, i, rec)
+}
+
+func (s *Server) recordRemove(r *http.Request) (map[string]interface{}, *apiError) {
+	return s.withRecord(r, func(d *domain, i int, rec *dnspod.Record) (map[string]interface{}, *apiError) {
+		d.log = append(d.log, "删除记录 "+rec.Name+" "+rec.Type+" "+rec.Value)
+		d.records = append(d.records[:i], d.records[i+1:]...)
+		return nil, nil
+	})
+}
+
+func (s *Server) recordRemark(r *http.Request) (map[string]interface{}, *apiError) {
+	return s.withRecord(r, func(d *domain, i int, rec *dnspod.Record) (map[string]interface{}, *apiError) {
+		rec.Remark = r.PostForm.Get("remark")
+		return nil, nil
+	})
+}
+
+func (s *Server) recordInfo(r *http.Request) (map[string]interface{}, *apiError) {
+	return s.withRecord(r, func(d *domain, i int, rec *dnspod.Record) (map[string]interface{}, *apiError) {
+		return map[string]interface{}{"record": rec}, nil
+	})
+}
+
+func (s *Server) recordStatus(r *http.Request) (map[string]interface{}, *apiError) {
+	return s.withRecord(r, func(d *domain, i int, rec *dnspod.Record) (map[string]interface{}, *apiError) {
+		switch r.PostForm.Get("status") {
+		case "enable":
+			rec.Enabled = true
+		case "disable":
+			rec.Enabled = false
+		default:
+			return nil, fail(3, "未知错误")
+		}
+		rec.UpdatedOn = now()
+		return nil, nil
+	})
+}
+
+func (s *Server) recordDDNS(r *http.Request) (map[string]interface{}, *apiError) {
+	return s.withRecord(r, func(d *domain, i int, rec *dnspod.Record) (map[string]interface{}, *apiError) {
+		f := r.PostForm
+		value := f.Get("value")
+		if value == "" {
+			value, _, _ = net.SplitHostPort(r.RemoteAddr)
+		}
+		if e := checkValue(rec.Type, value); e != nil {
+			return nil, e
+		}
+		if v := f.Get("record_line"); v != "" {
+			lineID, ok := s.lines[v]
+			if !ok {
+				return nil, fail(26, "记录线路错误")
+			}
+			rec.Line, rec.LineID = v, lineID
+		}
+		if v := f.Get("sub_domain"); v != "" {
+			rec.Name = v
+		}
+		rec.Value = value
+		rec.UpdatedOn = now()
+		d.log = append(d.log, "DDNS "+rec.Name+" "+rec.Value)
+		return map[string]interface{}{"record": map[string]string{
+			"id":    strconv.FormatInt(rec.ID, 10),
+			"name":  rec.Name,
+			"value": rec.Value,
+		}}, nil
+	})
+}
+
+func (s *Server) recordType(r *http.Request) (map[string]interface{}, *apiError) {
+	grade := r.PostForm.Get("domain_grade")
+	if grade == "" {
+		return nil, fail(6, "域名等级错误")
+	}
+	return map[string]interface{}{"types": s.typesOf(grade)}, nil
+}
+
+//recordLine lists the lines of the server, by line ID
+func (s *Server) recordLine(r *http.Request) (map[string]interface{}, *apiError) {
+	if s.findDomain(r.PostForm.Get("domain")) == nil {
+		return nil, fail(6, "域名ID错误")
+	}
+	if r.PostForm.Get("domain_grade") == "" {
+		return nil, fail(7, "域名等级错误")
+	}
+	lines := make([]string, 0, len(s.lines))
+	for l := range s.lines {
+		lines = append(lines, l)
+	}
+	sort.Slice(lines, func(i, j int) bool { return s.lines[lines[i]] < s.lines[lines[j]] })
+	return map[string]interface{}{"lines": lines, "line_ids": s.lines}, nil
+}
+
+//----------------------------------------------------------------------------------
+//# User.*
+
+func (s *Server) userDetail(r *http.Request) (map[string]interface{}, *apiError) {
+	return map[string]interface{}{"info": map[string]interface{}{"user": s.user}}, nil
+}
+
+func (s *Server) userModify(r *http.Request) (map[string]interface{}, *apiError) {
+	f := r.PostForm
+	if v := f.Get("real_name"); v != "" {
+		s.user.RealName = v
+	}
+	if v := f.Get("nick"); v != "" {
+		s.user.Nick = v
+	}
+	if v := f.Get("telephone"); v != "" {
+		s.user.Telephone = v
+	}
+	s.userLog = append(s.userLog, "修改用户信息")
+	return nil, nil
+}
+
+func (s *Server) userPasswd(r *http.Request) (map[string]interface{}, *apiError) {
+	if r.PostForm.Get("old_password") != s.pwd {
+		return nil, fail(6, "旧密码错误")
+	}
+	if len(r.PostForm.Get("new_password")) < 6 {
+		return nil, fail(7, "新密码不符合要求")
+	}
+	s.pwd = r.PostForm.Get("new_password")
+	s.userLog = append(s.userLog, "修改密码")
+	return nil, nil
+}
+
+func (s *Server) userEmail(r *http.Request) (map[string]interface{}, *apiError) {
+	f := r.PostForm
+	if f.Get("password") != s.pwd {
+		return nil, fail(6, "密码错误")
+	}
+	if f.Get("old_email") != s.user.Email {
+		return nil, fail(7, "旧邮箱错误")
+	}
+	if !strings.Contains(f.Get("new_email"), "@") {
+		return nil, fail(8, "新邮箱不合法")
+	}
+	s.user.Email = f.Get("new_email")
+	s.userLog = append(s.userLog, "修改邮箱")
+	return nil, nil
+}
+
+func (s *Server) userVerify(r *http.Request) (map[string]interface{}, *apiError) {
+	if r.PostForm.Get("telephone") == "" {
+		return nil, fail(6, "手机号码错误")
+	}
+	return map[string]interface{}{"user": dnspod.VerifyInfo{
+		Code: "dnspodtest",
+		Desc: "请发送短信 dnspodtest 进行验证",
+	}}, nil
+}
+
+func (s *Server) userLogs(r *http.Request) (map[string]interface{}, *apiError) {
+	return map[string]interface{}{"log": s.userLog}, nil
+}
@@ -0,0 +1,211 @@
+package dnspodtest_test
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/bigemon/dnspod"
+	"github.com/bigemon/dnspod/dnspodtest"
+)
+
+func newServer(t *testing.T) *dnspodtest.Server {
+	s := dnspodtest.NewServer("1234,token")
+	t.Cleanup(s.Close)
+	return s
+}
+
+//apiCode returns the status code of an *APIError, 0 if err is not one
+func apiCode(err error) int {
+	var apiErr *dnspod.APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.Code
+	}
+	return 0
+}
+
+func TestDomain(t *testing.T) {
+	s := newServer(t)
+	d := s.Dnspod()
+	id, err := d.Domain.Create("example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := d.Domain.Create("example.com"); !errors.Is(err, dnspod.ErrDomainExists) {
+		t.Errorf("create twice: err = %v, want ErrDomainExists", err)
+	}
+	info, err := d.Domain.Info("example.com")
+	if err != nil || info.ID != id || info.Name != "example.com" || info.Grade != "DP_Free" {
+		t.Fatalf("Info = %+v, %v", info, err)
+	}
+	list, err := d.Domain.List()
+	if err != nil || len(list) != 1 || list[0].Name != "example.com" {
+		t.Fatalf("List = %+v, %v", list, err)
+	}
+	if err := d.Domain.Status("example.com", false); err != nil {
+		t.Fatal(err)
+	}
+	if info, _ = d.Domain.Info("example.com"); info.Status {
+		t.Error("the domain is still enabled after Status(false)")
+	}
+	if err := d.Domain.Remove("example.com"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := d.Domain.Info("example.com"); !errors.Is(err, dnspod.ErrDomainNotFound) {
+		t.Errorf("Info after Remove: err = %v, want ErrDomainNotFound", err)
+	}
+}
+
+func TestRecord(t *testing.T) {
+	s := newServer(t)
+	s.AddDomain("example.com")
+	d := s.Dnspod()
+	id, err := d.Record.Create("example.com", dnspod.RTypeA, "192.0.2.1", dnspod.RecordOpt{SubDomain: "www", TTL: 3600})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := d.Record.Info("example.com", id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Name != "www" || r.Type != "A" || r.Value != "192.0.2.1" || r.TTL != 3600 || r.Line != "默认" || r.LineID != "0" || !r.Enabled {
+		t.Errorf("Info = %+v", r)
+	}
+	if err := d.Record.Modify("example.com", id, dnspod.RTypeA, "192.0.2.2", dnspod.RecordOpt{SubDomain: "www"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Record.Status("example.com", id, false); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Record.Remark("example.com", id, "web"); err != nil {
+		t.Fatal(err)
+	}
+	if r, _ = d.Record.Info("example.com", id); r.Value != "192.0.2.2" || r.Enabled || r.Remark != "web" {
+		t.Errorf("Info after Modify/Status/Remark = %+v", r)
+	}
+	list, err := d.Record.List("example.com", dnspod.RecordListOpt{SubDomain: "www"})
+	if err != nil || len(list.Records) != 1 || list.Records[0].ID != id {
+		t.Fatalf("List = %+v, %v", list.Records, err)
+	}
+	if err := d.Record.Remove("example.com", id); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := d.Record.Info("example.com", id); !errors.Is(err, dnspod.ErrRecordNotFound) {
+		t.Errorf("Info after Remove: err = %v, want ErrRecordNotFound", err)
+	}
+	//only the default NS records are left
+	if rs := s.Records("example.com"); len(rs) != len(dnspodtest.DefaultNS) || rs[0].Type != "NS" {
+		t.Errorf("Records = %+v", rs)
+	}
+}
+
+func TestRecordValidation(t *testing.T) {
+	s := newServer(t)
+	s.AddDomain("example.com")
+	d := s.Dnspod(dnspod.WithPreflight(false))
+	tests := []struct {
+		name  string
+		rtype dnspod.RType
+		value string
+		opt   dnspod.RecordOpt
+		code  int
+	}{
+		{"IPv6 in A", dnspod.RTypeA, "2001:db8::1", dnspod.RecordOpt{}, 34},
+		{"IPv4 in AAAA", dnspod.RTypeAAAA, "192.0.2.1", dnspod.RecordOpt{}, 34},
+		{"unquoted CAA", dnspod.RTypeCAA, "0 issue ca.example", dnspod.RecordOpt{}, 34},
+		{"MX priority", dnspod.RTypeMX, "mx.example.com.", dnspod.RecordOpt{MX: 50}, 30},
+		{"TTL of the free grade", dnspod.RTypeA, "192.0.2.1", dnspod.RecordOpt{TTL: 300}, 29},
+		{"unknown line", dnspod.RTypeA, "192.0.2.1", dnspod.RecordOpt{RecordLine: "火星"}, 26},
+		{"unknown type", "WKS", "192.0.2.1", dnspod.RecordOpt{}, 27},
+	}
+	for _, tt := range tests {
+		if _, err := d.Record.Create("example.com", tt.rtype, tt.value, tt.opt); apiCode(err) != tt.code {
+			t.Errorf("%s: err = %v, want code %d", tt.name, err, tt.code)
+		}
+	}
+	if rs := s.Records("example.com"); len(rs) != len(dnspodtest.DefaultNS) {
+		t.Errorf("%d records after the failed calls, want only the NS records", len(rs))
+	}
+}
+
+func TestFailNext(t *testing.T) {
+	s := newServer(t)
+	s.AddDomain("example.com")
+	d := s.Dnspod()
+	s.FailNext("Record.List", 3, "unknown error")
+	if _, err := d.Record.List("example.com"); apiCode(err) != 3 {
+		t.Errorf("err = %v, want code 3", err)
+	}
+	//only the next call fails
+	if _, err := d.Record.List("example.com"); err != nil {
+		t.Errorf("second call: %v", err)
+	}
+}
+
+func TestSetLinesAndTypes(t *testing.T) {
+	s := newServer(t)
+	s.AddDomain("example.com")
+	lines := map[string]string{"默认": "0", "电信": "10=0"}
+	s.SetLines(lines)
+	s.SetTypes([]string{"A", "NS"})
+	//the server keeps its own copy
+	lines["联通"] = "10=1"
+
+	d := s.Dnspod(dnspod.WithPreflight(false))
+	list, err := d.Record.Line("example.com", "DP_Free")
+	if err != nil || len(list.Lines) != 2 || list.IDs[dnspod.LineTelecom] != "10=0" {
+		t.Errorf("Line = %+v, %v", list, err)
+	}
+	if _, err := d.Record.Create("example.com", dnspod.RTypeA, "192.0.2.1", dnspod.RecordOpt{RecordLine: dnspod.LineUnicom}); apiCode(err) != 26 {
+		t.Errorf("removed line: err = %v, want code 26", err)
+	}
+	if _, err := d.Record.Create("example.com", dnspod.RTypeMX, "mx.example.com.", dnspod.RecordOpt{MX: 10}); apiCode(err) != 27 {
+		t.Errorf("removed type: err = %v, want code 27", err)
+	}
+
+	//the other servers are not affected
+	other := newServer(t)
+	other.AddDomain("example.com")
+	if _, err := other.Dnspod().Record.Create("example.com", dnspod.RTypeMX, "mx.example.com.", dnspod.RecordOpt{MX: 10}); err != nil {
+		t.Errorf("other server: %v", err)
+	}
+}
+
+func TestConcurrentServers(t *testing.T) {
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			s := dnspodtest.NewServer("1234,token")
+			defer s.Close()
+			s.AddDomain("example.com")
+			want := 0
+			if i%2 == 0 {
+				s.SetTypes([]string{"A"})
+				want = 27
+			}
+			_, err := s.Dnspod(dnspod.WithPreflight(false)).Record.Create("example.com", dnspod.RTypeTXT, "hello")
+			if apiCode(err) != want {
+				t.Errorf("server %d: err = %v, want code %d", i, err, want)
+			}
+		}(i)
+	}
+	wg.Wait()
+}
+
+func TestSetGradeTypes(t *testing.T) {
+	s := newServer(t)
+	s.AddDomain("example.com")
+	s.SetGradeTypes("DP_Free", []string{"A"})
+	d := s.Dnspod(dnspod.WithPreflight(false))
+	if types, err := d.Record.Type("DP_Free"); err != nil || len(types) != 1 || types[0] != dnspod.RTypeA {
+		t.Errorf("Type(DP_Free) = %v, %v", types, err)
+	}
+	if types, err := d.Record.Type("DP_Plus"); err != nil || len(types) != len(dnspodtest.DefaultTypes()) {
+		t.Errorf("Type(DP_Plus) = %v, %v, want the default types", types, err)
+	}
+	if _, err := d.Record.Create("example.com", dnspod.RTypeTXT, "hello"); apiCode(err) != 27 {
+		t.Errorf("err = %v, want code 27", err)
+	}
+}
@@ -1,17 +1,15 @@
 package dnspod
 
 import (
+	"context"
 	"encoding/json"
-	"errors"
-	"net/http"
 	"net/url"
 	"strconv"
 )
 
 //DomainAPI packaged some dnspod domain APIs
 type DomainAPI struct {
-	loginToken string
-	client     *http.Client
+	c *client
 }
 
 //DomainInfo is the struct of domain statistic info
@@ -61,10 +59,12 @@ type DomainCreateOpt struct {
 
 //Create a new domain record
 func (p *DomainAPI) Create(domain string, opt ...DomainCreateOpt) (domainID int64, err error) {
-	params := url.Values{}
-	params.Set("login_token", p.loginToken)
-	params.Set("format", "json")
+	return p.CreateContext(context.Background(), domain, opt...)
+}
 
+//CreateContext is like Create but carries ctx for cancellation and deadlines
+func (p *DomainAPI) CreateContext(ctx context.Context, domain string, opt ...DomainCreateOpt) (domainID int64, err error) {
+	params := url.Values{}
 	params.Set("domain", domain)
 	if len(opt) > 0 && opt[0].GroupID != 0 {
 		params.Set("group_id", strconv.Itoa(opt[0].GroupID))
@@ -73,7 +73,7 @@ func (p *DomainAPI) Create(domain string, opt ...DomainCreateOpt) (domainID int6
 		params.Set("is_mark", opt[0].IsMark.String())
 	}
 
-	res, err := simpleHTTP(p.client, "POST", "https://dnsapi.cn/Domain.Create", params)
+	res, err := p.c.post(ctx, "Domain.Create", params)
 	if err != nil {
 		return
 	}
@@ -87,7 +87,7 @@ func (p *DomainAPI) Create(domain string, opt ...DomainCreateOpt) (domainID int6
 		return
 	}
 	if jsonRes.Status.Code != 1 {
-		return 0, errors.New(jsonRes.Status.Message)
+		return 0, newAPIError("Domain.Create", params, jsonRes.Status)
 	}
 	return jsonRes.Domain.ID, nil
 }
@@ -125,9 +125,12 @@ type DomainListOpt struct {
 
 //List the domain records for the specified optional filter criteria
 func (p *DomainAPI) List(opt ...DomainListOpt) (list []Domain, err error) {
+	return p.ListContext(context.Background(), opt...)
+}
+
+//ListContext is like List but carries ctx for cancellation and deadlines
+func (p *DomainAPI) ListContext(ctx context.Context, opt ...DomainListOpt) (list []Domain, err error) {
 	params := url.Values{}
-	params.Set("login_token", p.loginToken)
-	params.Set("format", "json")
 	var o DomainListOpt
 	if len(opt) > 0 {
 		o = opt[0]
@@ -148,7 +151,7 @@ func (p *DomainAPI) List(opt ...DomainListOpt) (list []Domain, err error) {
 		params.Set("type", string(o.Type))
 	}
 
-	res, err := simpleHTTP(p.client, "POST", "https://dnsapi.cn/Domain.List", params)
+	res, err := p.c.post(ctx, "Domain.List", params)
 	if err != nil {
 		return
 	}
@@ -159,21 +162,27 @@ func (p *DomainAPI) List(opt ...DomainListOpt) (list []Domain, err error) {
 	if err = json.Unmarshal(res, &jsonRes); err != nil {
 		return
 	}
+	//code 9: there is no domain matching the criteria
+	if jsonRes.Status.Code == 9 {
+		return jsonRes.Domains, nil
+	}
 	if jsonRes.Status.Code != 1 {
-		return list, errors.New(jsonRes.Status.Message)
+		return list, newAPIError("Domain.List", params, jsonRes.Status)
 	}
 	return jsonRes.Domains, nil
 }
 
 //Remove a domain record
 func (p *DomainAPI) Remove(domain string) (err error) {
-	params := url.Values{}
-	params.Set("login_token", p.loginToken)
-	params.Set("format", "json")
+	return p.RemoveContext(context.Background(), domain)
+}
 
+//RemoveContext is like Remove but carries ctx for cancellation and deadlines
+func (p *DomainAPI) RemoveContext(ctx context.Context, domain string) (err error) {
+	params := url.Values{}
 	params.Set("domain", domain)
 
-	res, err := simpleHTTP(p.client, "POST", "https://dnsapi.cn/Domain.Remove", params)
+	res, err := p.c.post(ctx, "Domain.Remove", params)
 	if err != nil {
 		return
 	}
@@ -184,21 +193,23 @@ func (p *DomainAPI) Remove(domain string) (err error) {
 		return
 	}
 	if jsonRes.Status.Code != 1 {
-		return errors.New(jsonRes.Status.Message)
+		return newAPIError("Domain.Remove", params, jsonRes.Status)
 	}
 	return nil
 }
 
 //Status setting a domain enabled or disable
 func (p *DomainAPI) Status(domain string, enable Enable) (err error) {
-	params := url.Values{}
-	params.Set("login_token", p.loginToken)
-	params.Set("format", "json")
+	return p.StatusContext(context.Background(), domain, enable)
+}
 
+//StatusContext is like Status but carries ctx for cancellation and deadlines
+func (p *DomainAPI) StatusContext(ctx context.Context, domain string, enable Enable) (err error) {
+	params := url.Values{}
 	params.Set("domain", domain)
 	params.Set("status", enable.String())
 
-	res, err := simpleHTTP(p.client, "POST", "https://dnsapi.cn/Domain.Status", params)
+	res, err := p.c.post(ctx, "Domain.Status", params)
 	if err != nil {
 		return
 	}
@@ -209,20 +220,22 @@ func (p *DomainAPI) Status(domain string, enable Enable) (err error) {
 		return
 	}
 	if jsonRes.Status.Code != 1 {
-		return errors.New(jsonRes.Status.Message)
+		return newAPIError("Domain.Status", params, jsonRes.Status)
 	}
 	return nil
 }
 
 //Info get a domain record
 func (p *DomainAPI) Info(domain string) (info Domain, err error) {
-	params := url.Values{}
-	params.Set("login_token", p.loginToken)
-	params.Set("format", "json")
+	return p.InfoContext(context.Background(), domain)
+}
 
+//InfoContext is like Info but carries ctx for cancellation and deadlines
+func (p *DomainAPI) InfoContext(ctx context.Context, domain string) (info Domain, err error) {
+	params := url.Values{}
 	params.Set("domain", domain)
 
-	res, err := simpleHTTP(p.client, "POST", "https://dnsapi.cn/Domain.Info", params)
+	res, err := p.c.post(ctx, "Domain.Info", params)
 	if err != nil {
 		return
 	}
@@ -234,7 +247,7 @@ func (p *DomainAPI) Info(domain string) (info Domain, err error) {
 		return
 	}
 	if jsonRes.Status.Code != 1 {
-		return info, errors.New(jsonRes.Status.Message)
+		return info, newAPIError("Domain.Info", params, jsonRes.Status)
 	}
 	return jsonRes.Domain, nil
 }
@@ -247,10 +260,12 @@ type DomainLogOpt struct {
 
 //Log lists the log of the specified domain
 func (p *DomainAPI) Log(domain string, opt ...DomainLogOpt) (log []string, err error) {
-	params := url.Values{}
-	params.Set("login_token", p.loginToken)
-	params.Set("format", "json")
+	return p.LogContext(context.Background(), domain, opt...)
+}
 
+//LogContext is like Log but carries ctx for cancellation and deadlines
+func (p *DomainAPI) LogContext(ctx context.Context, domain string, opt ...DomainLogOpt) (log []string, err error) {
+	params := url.Values{}
 	params.Set("domain", domain)
 	var o DomainLogOpt
 	if len(opt) > 0 {
@@ -263,7 +278,7 @@ func (p *DomainAPI) Log(domain string, opt ...DomainLogOpt) (log []string, err e
 		params.Set("offset", strconv.Itoa(o.Offset))
 	}
 
-	res, err := simpleHTTP(p.client, "POST", "https://dnsapi.cn/Domain.Log", params)
+	res, err := p.c.post(ctx, "Domain.Log", params)
 	if err != nil {
 		return
 	}
@@ -275,7 +290,7 @@ func (p *DomainAPI) Log(domain string, opt ...DomainLogOpt) (log []string, err e
 		return
 	}
 	if jsonRes.Status.Code != 1 {
-		return log, errors.New(jsonRes.Status.Message)
+		return log, newAPIError("Domain.Log", params, jsonRes.Status)
 	}
 	return jsonRes.Log, nil
 }
@@ -0,0 +1,154 @@
+package dnspod
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+//Sentinel errors, matched by errors.Is against an *APIError
+var (
+	//ErrAuth login failed, token invalid or the account is locked
+	ErrAuth = errors.New("dnspod: authentication failed")
+	//ErrPermission the token has no permission on the operation or the domain
+	ErrPermission = errors.New("dnspod: permission denied")
+	//ErrRateLimited the API usage exceeds the limit
+	ErrRateLimited = errors.New("dnspod: rate limited")
+	//ErrDomainNotFound the domain (or domain ID) is invalid or does not exist
+	ErrDomainNotFound = errors.New("dnspod: domain not found")
+	//ErrDomainExists the domain has already been added
+	ErrDomainExists = errors.New("dnspod: domain already exists")
+	//ErrDomainLocked the domain is locked or banned
+	ErrDomainLocked = errors.New("dnspod: domain locked")
+	//ErrRecordNotFound the record ID is invalid, or there is no record
+	ErrRecordNotFound = errors.New("dnspod: record not found")
+)
+
+//APIError is returned when dnspod answers with a status code other than 1
+type APIError struct {
+	Endpoint  string     //The API called, such as "Record.List"
+	Code      int        //Status.Code returned by dnspod
+	Message   string     //Status.Message returned by dnspod
+	CreatedAt Time       //Status.CreatedAt returned by dnspod
+	Params    url.Values //The request params, login_token and the passwords are redacted
+}
+
+//Error interface
+func (e *APIError) Error() string {
+	return fmt.Sprintf("dnspod: %s: code %d: %s", e.Endpoint, e.Code, e.Message)
+}
+
+//Is reports whether the error matches one of the sentinel errors
+func (e *APIError) Is(target error) bool {
+	return target != nil && classify(e.Endpoint, e.Code) == target
+}
+
+//HTTPError is returned when dnspod answers with a non-2xx HTTP status
+type HTTPError struct {
+	Endpoint   string //The API called, such as "Record.List"
+	StatusCode int
+	Status     string
+	Snippet    string //The beginning of the response body
+}
+
+//Error interface
+func (e *HTTPError) Error() string {
+	msg := "dnspod: unexpected HTTP status " + e.Status
+	if e.Endpoint != "" {
+		msg = "dnspod: " + e.Endpoint + ": unexpected HTTP status " + e.Status
+	}
+	if e.Snippet != "" {
+		msg += ": " + e.Snippet
+	}
+	return msg
+}
+
+//ResponseError is returned when the response body is too large or is not valid JSON
+type ResponseError struct {
+	Endpoint string //The API called, such as "Record.List"
+	Reason   string
+	Snippet  string //The beginning of the response body
+}
+
+//Error interface
+func (e *ResponseError) Error() string {
+	return fmt.Sprintf("dnspod: %s: %s: %q", e.Endpoint, e.Reason, e.Snippet)
+}
+
+//secretParams are redacted from APIError.Params
+var secretParams = map[string]bool{
+	"login_token":  true,
+	"password":     true,
+	"old_password": true,
+	"new_password": true,
+}
+
+//newAPIError builds an *APIError from the status of a failed call
+func newAPIError(endpoint string, params url.Values, s Status) error {
+	p := url.Values{}
+	for k, v := range params {
+		if secretParams[k] {
+			v = []string{"REDACTED"}
+		}
+		p[k] = v
+	}
+	return &APIError{
+		Endpoint:  endpoint,
+		Code:      s.Code,
+		Message:   s.Message,
+		CreatedAt: s.CreatedAt,
+		Params:    p,
+	}
+}
+
+//classify maps a dnspod status code to a sentinel error.
+//Positive codes are defined per endpoint, negative codes are common to all of them.
+func classify(endpoint string, code int) error {
+	switch code {
+	case -1, -8, 83:
+		return ErrAuth
+	case -2:
+		return ErrRateLimited
+	case -3, -4, -7:
+		return ErrPermission
+	case -15:
+		return ErrDomainLocked
+	}
+	module := endpoint
+	if i := strings.IndexByte(endpoint, '.'); i >= 0 {
+		module = endpoint[:i]
+	}
+	switch module {
+	case "Domain":
+		switch {
+		case endpoint == "Domain.List":
+			//6 and 7 are an invalid offset and length
+		case code == 6 && endpoint != "Domain.Create":
+			return ErrDomainNotFound
+		case code == 7 && endpoint == "Domain.Create":
+			return ErrDomainExists
+		case code == 7:
+			return ErrDomainLocked
+		case code == 8 && (endpoint == "Domain.Info" || endpoint == "Domain.Status"),
+			code == 9 && endpoint == "Domain.Remove":
+			return ErrPermission
+		}
+	case "Record":
+		switch {
+		case code == 6:
+			return ErrDomainNotFound
+		case code == 7 && endpoint != "Record.List":
+			return ErrPermission
+		case code == 8 && endpoint != "Record.List":
+			return ErrRecordNotFound
+		case code == 9 && endpoint == "Record.List":
+			return ErrPermission
+		case code == 10 && endpoint == "Record.List":
+			return ErrRecordNotFound
+		case code == 21:
+			return ErrDomainLocked
+		}
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package dnspod_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/bigemon/dnspod"
+	"github.com/bigemon/dnspod/dnspodtest"
+)
+
+//newServer starts a dnspodtest.Server closed at the end of the test
+func newServer(t *testing.T) *dnspodtest.Server {
+	s := dnspodtest.NewServer("1234,token")
+	t.Cleanup(s.Close)
+	return s
+}
+
+func TestSentinelErrors(t *testing.T) {
+	s := newServer(t)
+	s.AddDomain("example.com")
+	d := s.Dnspod()
+	tests := []struct {
+		endpoint string
+		code     int
+		call     func() error
+		want     error
+	}{
+		{"Record.List", -1, func() error { _, err := d.Record.List("example.com"); return err }, dnspod.ErrAuth},
+		{"Record.List", -2, func() error { _, err := d.Record.List("example.com"); return err }, dnspod.ErrRateLimited},
+		{"Record.List", -3, func() error { _, err := d.Record.List("example.com"); return err }, dnspod.ErrPermission},
+		{"Record.List", -15, func() error { _, err := d.Record.List("example.com"); return err }, dnspod.ErrDomainLocked},
+		{"Record.Info", 8, func() error { _, err := d.Record.Info("example.com", 1); return err }, dnspod.ErrRecordNotFound},
+		{"Domain.Info", 6, func() error { _, err := d.Domain.Info("example.com"); return err }, dnspod.ErrDomainNotFound},
+		{"Domain.Create", 7, func() error { _, err := d.Domain.Create("example.com"); return err }, dnspod.ErrDomainExists},
+	}
+	for _, tt := range tests {
+		s.FailNext(tt.endpoint, tt.code, "failed")
+		err := tt.call()
+		var apiErr *dnspod.APIError
+		if !errors.Is(err, tt.want) || !errors.As(err, &apiErr) || apiErr.Code != tt.code || apiErr.Endpoint != tt.endpoint {
+			t.Errorf("%s code %d: err = %v, want %v", tt.endpoint, tt.code, err, tt.want)
+		}
+	}
+
+	//the real answers of the fake server
+	if _, err := d.Domain.Info("missing.com"); !errors.Is(err, dnspod.ErrDomainNotFound) {
+		t.Errorf("Info of a missing domain: err = %v, want ErrDomainNotFound", err)
+	}
+	if _, err := d.Record.List("missing.com"); !errors.Is(err, dnspod.ErrDomainNotFound) {
+		t.Errorf("List of a missing domain: err = %v, want ErrDomainNotFound", err)
+	}
+	bad := dnspod.NewDnspod("1234,wrong", dnspod.WithBaseURL(s.URL), dnspod.WithRetry(dnspod.RetryPolicy{}))
+	if _, err := bad.Domain.List(); !errors.Is(err, dnspod.ErrAuth) {
+		t.Errorf("wrong token: err = %v, want ErrAuth", err)
+	}
+
+	//the invalid offset and length of Domain.List are not about a domain
+	_, err := d.Domain.List(dnspod.DomainListOpt{Length: 5000})
+	var apiErr *dnspod.APIError
+	if !errors.As(err, &apiErr) || apiErr.Code != 7 {
+		t.Fatalf("Domain.List with a length of 5000: err = %v, want code 7", err)
+	}
+	for _, sentinel := range []error{dnspod.ErrDomainNotFound, dnspod.ErrDomainLocked, dnspod.ErrDomainExists} {
+		if errors.Is(err, sentinel) {
+			t.Errorf("Domain.List code 7 matches %v", sentinel)
+		}
+	}
+}
+
+func TestAPIErrorRedactsPasswords(t *testing.T) {
+	s := newServer(t)
+	s.SetPassword("old-secret")
+	d := s.Dnspod()
+	for _, err := range []error{
+		d.User.ModifyPassword("wrong-secret", "new-secret"),
+		d.User.ModifyEmail("wrong-secret", "a@example.com", "b@example.com"),
+	} {
+		var apiErr *dnspod.APIError
+		if !errors.As(err, &apiErr) {
+			t.Fatalf("err = %v, want an *APIError", err)
+		}
+		for k, v := range apiErr.Params {
+			if v[0] == "wrong-secret" || v[0] == "new-secret" {
+				t.Errorf("%s: Params[%s] is not redacted", apiErr.Endpoint, k)
+			}
+		}
+		if apiErr.Params.Get("login_token") != "" && apiErr.Params.Get("login_token") != "REDACTED" {
+			t.Errorf("%s: login_token is not redacted", apiErr.Endpoint)
+		}
+	}
+}
@@ -0,0 +1,3 @@
+module github.com/bigemon/dnspod
+
+go 1.21
@@ -0,0 +1,300 @@
+package dnspod
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+//IPVersion is the version of the IP to detect
+type IPVersion int
+
+const (
+	//IPv4 detects the WAN IPv4 address, for A records
+	IPv4 IPVersion = 4
+	//IPv6 detects the WAN IPv6 address, for AAAA records
+	IPv6 IPVersion = 6
+)
+
+//IPDetector detects the WAN IP of this host
+type IPDetector interface {
+	DetectIP(ctx context.Context) (net.IP, error)
+}
+
+//IPDetectorFunc adapts a func to IPDetector
+type IPDetectorFunc func(ctx context.Context) (net.IP, error)
+
+//DetectIP interface
+func (f IPDetectorFunc) DetectIP(ctx context.Context) (net.IP, error) {
+	return f(ctx)
+}
+
+//bogons is the reserved networks that are never a WAN IP, besides the private/loopback/link-local ones
+var bogons = func() (nets []*net.IPNet) {
+	for _, s := range []string{
+		"0.0.0.0/8",       //"this" network
+		"100.64.0.0/10",   //carrier-grade NAT
+		"192.0.0.0/24",    //IETF protocol assignments
+		"192.0.2.0/24",    //TEST-NET-1
+		"198.18.0.0/15",   //benchmarking
+		"198.51.100.0/24", //TEST-NET-2
+		"203.0.113.0/24",  //TEST-NET-3
+		"240.0.0.0/4",     //reserved
+		"2001:db8::/32",   //documentation
+		"100::/64",        //discard-only
+		"64:ff9b::/96",    //NAT64
+	} {
+		_, n, _ := net.ParseCIDR(s)
+		nets = append(nets, n)
+	}
+	return
+}()
+
+//ValidatePublicIP rejects the IPs which can not be the WAN IP of the version:
+//private, loopback, link-local, multicast, unspecified and other reserved (bogon) addresses
+func ValidatePublicIP(ip net.IP, version IPVersion) error {
+	switch {
+	case ip == nil:
+		return errors.New("dnspod: no IP found")
+	case version == IPv4 && ip.To4() == nil:
+		return fmt.Errorf("dnspod: %s is not an IPv4 address", ip)
+	case version == IPv6 && ip.To4() != nil:
+		return fmt.Errorf("dnspod: %s is not an IPv6 address", ip)
+	case ip.IsPrivate(), ip.IsLoopback(), ip.IsLinkLocalUnicast(), ip.IsMulticast(),
+		ip.IsUnspecified(), ip.IsInterfaceLocalMulticast(), ip.IsLinkLocalMulticast():
+		return fmt.Errorf("dnspod: %s is not a public address", ip)
+	}
+	for _, n := range bogons {
+		if n.Contains(ip) {
+			return fmt.Errorf("dnspod: %s is a reserved address", ip)
+		}
+	}
+	return nil
+}
+
+//findIP returns the first public IP of the version in text
+func findIP(text string, version IPVersion) (net.IP, error) {
+	fields := strings.FieldsFunc(text, func(r rune) bool {
+		return !(r >= '0' && r <= '9' || r >= 'a' && r <= 'f' || r >= 'A' && r <= 'F' || r == '.' || r == ':')
+	})
+	err := errors.New("dnspod: no IP found")
+	for _, f := range fields {
+		ip := net.ParseIP(strings.Trim(f, "."))
+		if ip == nil {
+			continue
+		}
+		if err = ValidatePublicIP(ip, version); err == nil {
+			return ip, nil
+		}
+	}
+	return nil, err
+}
+
+//HTTPDetector asks an HTTP echo service, the first public IP of the body is used
+type HTTPDetector struct {
+	URL     string
+	Version IPVersion
+	Client  *http.Client //http.DefaultClient when nil
+}
+
+//DetectIP interface
+func (p *HTTPDetector) DetectIP(ctx context.Context) (net.IP, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", p.URL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", DefaultUserAgent)
+	hc := p.Client
+	if hc == nil {
+		hc = http.DefaultClient
+	}
+	resp, err := hc.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("dnspod: %s: unexpected HTTP status %s", p.URL, resp.Status)
+	}
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if err != nil {
+		return nil, err
+	}
+	ip, err := findIP(string(body), p.Version)
+	if err != nil {
+		return nil, fmt.Errorf("%w (from %s)", err, p.URL)
+	}
+	return ip, nil
+}
+
+//InterfaceDetector uses the public address of a local network interface,
+//for hosts having the WAN IP on an interface (PPPoE, IPv6 without NAT...)
+type InterfaceDetector struct {
+	Name    string //The interface name, such as "eth0", all interfaces are checked when empty
+	Version IPVersion
+}
+
+//DetectIP interface
+func (p *InterfaceDetector) DetectIP(ctx context.Context) (net.IP, error) {
+	var ifaces []net.Interface
+	if p.Name != "" {
+		iface, err := net.InterfaceByName(p.Name)
+		if err != nil {
+			return nil, err
+		}
+		ifaces = append(ifaces, *iface)
+	} else {
+		var err error
+		if ifaces, err = net.Interfaces(); err != nil {
+			return nil, err
+		}
+	}
+	for _, iface := range ifaces {
+		if iface.Flags&net.FlagUp == 0 {
+			continue
+		}
+		addrs, err := iface.Addrs()
+		if err != nil {
+			continue
+		}
+		for _, a := range addrs {
+			if n, ok := a.(*net.IPNet); ok && ValidatePublicIP(n.IP, p.Version) == nil {
+				return n.IP, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("dnspod: no public IPv%d address on the interfaces", p.Version)
+}
+
+//CommandDetector runs a command, the first public IP of its output is used
+type CommandDetector struct {
+	Command string
+	Args    []string
+	Version IPVersion
+}
+
+//DetectIP interface
+func (p *CommandDetector) DetectIP(ctx context.Context) (net.IP, error) {
+	out, err := exec.CommandContext(ctx, p.Command, p.Args...).Output()
+	if err != nil {
+		return nil, fmt.Errorf("dnspod: %s: %w", p.Command, err)
+	}
+	ip, err := findIP(string(out), p.Version)
+	if err != nil {
+		return nil, fmt.Errorf("%w (from %s)", err, p.Command)
+	}
+	return ip, nil
+}
+
+//DefaultDetectTimeout is the deadline of each detector of a QuorumDetector without Timeout
+const DefaultDetectTimeout = 10 * time.Second
+
+//QuorumDetector asks all Detectors at once, an IP is accepted when at least Quorum of them agree.
+//A detector not answering within Timeout counts as a failed one.
+//DetectIP fails without asking when Quorum exceeds the number of Detectors.
+type QuorumDetector struct {
+	Detectors []IPDetector
+	Quorum    int           //1 when not set
+	Timeout   time.Duration //The deadline of each detector, DefaultDetectTimeout when not set
+}
+
+//DetectIP interface
+func (p *QuorumDetector) DetectIP(ctx context.Context) (net.IP, error) {
+	quorum := p.Quorum
+	if quorum < 1 {
+		quorum = 1
+	}
+	if quorum > len(p.Detectors) {
+		return nil, fmt.Errorf("dnspod: invalid QuorumDetector: quorum %d exceeds the %d detectors", quorum, len(p.Detectors))
+	}
+	timeout := p.Timeout
+	if timeout <= 0 {
+		timeout = DefaultDetectTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	type result struct {
+		ip  net.IP
+		err error
+	}
+	results := make(chan result, len(p.Detectors))
+	var wg sync.WaitGroup
+	for _, d := range p.Detectors {
+		wg.Add(1)
+		go func(d IPDetector) {
+			defer wg.Done()
+			ip, err := d.DetectIP(ctx)
+			results <- result{ip, err}
+		}(d)
+	}
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+	votes := map[string]int{}
+	var errs []string
+	for r := range results {
+		if r.err != nil {
+			errs = append(errs, r.err.Error())
+			continue
+		}
+		key := r.ip.String()
+		if votes[key]++; votes[key] >= quorum {
+			return r.ip, nil
+		}
+	}
+	return nil, fmt.Errorf("dnspod: no IP reached the quorum of %d, votes: %v, errors: [%s]", quorum, votes, strings.Join(errs, "; "))
+}
+
+//DefaultIPDetector asks several HTTP echo services and requires 2 of them to agree
+//hc:			The http.Client used, http.DefaultClient when nil
+func DefaultIPDetector(version IPVersion, hc *http.Client) IPDetector {
+	urls := []string{"https://api.ipify.org", "https://ipv4.icanhazip.com", "https://4.ipw.cn"}
+	if version == IPv6 {
+		urls = []string{"https://api6.ipify.org", "https://ipv6.icanhazip.com", "https://6.ipw.cn"}
+	}
+	q := &QuorumDetector{Quorum: 2}
+	for _, u := range urls {
+		q.Detectors = append(q.Detectors, &HTTPDetector{URL: u, Version: version, Client: hc})
+	}
+	return q
+}
+
+//WithIPDetector sets the IPDetector used by MyWANIP (IPv4) or MyWANIPv6 (IPv6)
+func WithIPDetector(version IPVersion, d IPDetector) Option {
+	return func(c *client) {
+		if c.detectors == nil {
+			c.detectors = map[IPVersion]IPDetector{}
+		}
+		c.detectors[version] = d
+	}
+}
+
+//detectIP detects the WAN IP with the detector of the version, within the WithTimeout of the client
+func (p *Dnspod) detectIP(ctx context.Context, version IPVersion) (ip string, err error) {
+	if p.client.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, p.client.timeout)
+		defer cancel()
+	}
+	d := p.client.detectors[version]
+	if d == nil {
+		d = DefaultIPDetector(version, p.client.httpClient)
+	}
+	v, err := d.DetectIP(ctx)
+	if err != nil {
+		return
+	}
+	if err = ValidatePublicIP(v, version); err != nil {
+		return
+	}
+	return v.String(), nil
+}
@@ -0,0 +1,142 @@
+package dnspod
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestValidatePublicIP(t *testing.T) {
+	tests := []struct {
+		ip      string
+		version IPVersion
+		ok      bool
+	}{
+		{"1.2.3.4", IPv4, true},
+		{"2400:3200::1", IPv6, true},
+		{"1.2.3.4", IPv6, false},
+		{"2400:3200::1", IPv4, false},
+		{"10.0.0.1", IPv4, false},
+		{"192.168.1.1", IPv4, false},
+		{"127.0.0.1", IPv4, false},
+		{"169.254.1.1", IPv4, false},
+		{"100.64.0.1", IPv4, false},
+		{"192.0.2.1", IPv4, false},
+		{"224.0.0.1", IPv4, false},
+		{"0.0.0.0", IPv4, false},
+		{"::1", IPv6, false},
+		{"fe80::1", IPv6, false},
+		{"fd00::1", IPv6, false},
+		{"2001:db8::1", IPv6, false},
+		{"64:ff9b::1.2.3.4", IPv6, false},
+	}
+	for _, tt := range tests {
+		if err := ValidatePublicIP(net.ParseIP(tt.ip), tt.version); (err == nil) != tt.ok {
+			t.Errorf("ValidatePublicIP(%s, IPv%d) = %v, want ok %v", tt.ip, tt.version, err, tt.ok)
+		}
+	}
+	if err := ValidatePublicIP(nil, IPv4); err == nil {
+		t.Error("ValidatePublicIP(nil) succeeded")
+	}
+}
+
+func TestFindIP(t *testing.T) {
+	tests := []struct {
+		text    string
+		version IPVersion
+		want    string
+	}{
+		{"1.2.3.4\n", IPv4, "1.2.3.4"},
+		{`{"ip":"1.2.3.4"}`, IPv4, "1.2.3.4"},
+		{"Current IP Address: 1.2.3.4.", IPv4, "1.2.3.4"},
+		{"via 10.0.0.1, from 1.2.3.4", IPv4, "1.2.3.4"},
+		{"addr 2400:3200::1/64", IPv6, "2400:3200::1"},
+		{"1.2.3.4 2400:3200::1", IPv6, "2400:3200::1"},
+		{"fe80::1 2400:3200::1", IPv6, "2400:3200::1"},
+	}
+	for _, tt := range tests {
+		if ip, err := findIP(tt.text, tt.version); err != nil || ip.String() != tt.want {
+			t.Errorf("findIP(%q) = %v, %v, want %s", tt.text, ip, err, tt.want)
+		}
+	}
+	for _, text := range []string{"", "<html>no ip</html>", "10.0.0.1", "2400:3200::1"} {
+		if ip, err := findIP(text, IPv4); err == nil {
+			t.Errorf("findIP(%q) = %v, want an error", text, ip)
+		}
+	}
+}
+
+//staticDetector answers ip, or fails when ip is empty
+func staticDetector(ip string) IPDetector {
+	return IPDetectorFunc(func(ctx context.Context) (net.IP, error) {
+		if ip == "" {
+			return nil, errors.New("unreachable")
+		}
+		return net.ParseIP(ip), nil
+	})
+}
+
+//hungDetector answers when ctx is done
+var hungDetector = IPDetectorFunc(func(ctx context.Context) (net.IP, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+})
+
+func TestQuorumDetector(t *testing.T) {
+	tests := []struct {
+		name      string
+		quorum    int
+		detectors []IPDetector
+		want      string
+	}{
+		{"quorum reached", 2, []IPDetector{staticDetector("1.2.3.4"), staticDetector("1.2.3.5"), staticDetector("1.2.3.4")}, "1.2.3.4"},
+		{"errors are not votes", 2, []IPDetector{staticDetector(""), staticDetector("1.2.3.4"), staticDetector("1.2.3.4")}, "1.2.3.4"},
+		{"default quorum", 0, []IPDetector{staticDetector(""), staticDetector("1.2.3.4")}, "1.2.3.4"},
+		{"no agreement", 2, []IPDetector{staticDetector("1.2.3.4"), staticDetector("1.2.3.5"), staticDetector("")}, ""},
+		{"quorum exceeds the detectors", 3, []IPDetector{staticDetector("1.2.3.4"), staticDetector("1.2.3.4")}, ""},
+		{"hung detector", 2, []IPDetector{staticDetector("1.2.3.4"), hungDetector, staticDetector("1.2.3.4")}, "1.2.3.4"},
+		{"hung detector needed", 2, []IPDetector{staticDetector("1.2.3.4"), hungDetector}, ""},
+	}
+	for _, tt := range tests {
+		q := &QuorumDetector{Detectors: tt.detectors, Quorum: tt.quorum, Timeout: 50 * time.Millisecond}
+		ip, err := q.DetectIP(context.Background())
+		if tt.want == "" {
+			if err == nil {
+				t.Errorf("%s: DetectIP = %v, want an error", tt.name, ip)
+			}
+		} else if err != nil || ip.String() != tt.want {
+			t.Errorf("%s: DetectIP = %v, %v, want %s", tt.name, ip, err, tt.want)
+		}
+	}
+}
+
+func TestDetectIP(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("1.2.3.4\n"))
+	}))
+	defer s.Close()
+	d := NewDnspod("1,token", WithIPDetector(IPv4, &HTTPDetector{URL: s.URL, Version: IPv4}))
+	if ip, err := d.MyWANIP(); err != nil || ip != "1.2.3.4" {
+		t.Errorf("MyWANIP = %s, %v", ip, err)
+	}
+
+	//a private IP of the detector is rejected
+	d = NewDnspod("1,token", WithIPDetector(IPv4, staticDetector("10.0.0.1")))
+	if ip, err := d.MyWANIP(); err == nil {
+		t.Errorf("MyWANIP = %s, want an error", ip)
+	}
+
+	//WithTimeout bounds the detection
+	d = NewDnspod("1,token", WithTimeout(50*time.Millisecond), WithIPDetector(IPv6, hungDetector))
+	start := time.Now()
+	if _, err := d.MyWANIPv6(); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("err = %v, want context.DeadlineExceeded", err)
+	}
+	if time.Since(start) > 5*time.Second {
+		t.Errorf("MyWANIPv6 took %v", time.Since(start))
+	}
+}
@@ -0,0 +1,210 @@
+package dnspod
+
+import "context"
+
+//DefaultPageSize is the page size used by the iterators when opt.Length is not set
+const DefaultPageSize = 100
+
+//pager loads the pages of a list API one by one
+type pager struct {
+	ctx    context.Context
+	offset int
+	length int
+	size   int //size of the current page
+	pos    int //position in the current page
+	done   bool
+	err    error
+	//fetch loads the page at offset into the typed iterator, returns the size of the page
+	fetch func(ctx context.Context, offset, length int) (size int, err error)
+}
+
+func newPager(ctx context.Context, offset, length int, fetch func(ctx context.Context, offset, length int) (int, error)) pager {
+	if length <= 0 {
+		length = DefaultPageSize
+	}
+	return pager{ctx: ctx, offset: offset, length: length, fetch: fetch}
+}
+
+func (p *pager) next() bool {
+	if p.err != nil {
+		return false
+	}
+	p.pos++
+	if p.pos < p.size {
+		return true
+	}
+	if p.done {
+		return false
+	}
+	size, err := p.fetch(p.ctx, p.offset, p.length)
+	if err != nil {
+		p.err = err
+		return false
+	}
+	p.offset += size
+	p.size, p.pos = size, 0
+	//a short page is the last one
+	p.done = size < p.length
+	return size > 0
+}
+
+//RecordIterator pages through the records of a domain
+//	it := d.Record.Iterate("example.com")
+//	for it.Next() {
+//		r := it.Record()
+//		...
+//	}
+//	if err := it.Err(); err != nil {
+//		...
+//	}
+type RecordIterator struct {
+	pager
+	page   []Record
+	domain RecordDomain
+}
+
+//Next advances to the next record, it returns false when there are no more records or on error
+func (it *RecordIterator) Next() bool {
+	return it.next()
+}
+
+//Record returns the current record
+func (it *RecordIterator) Record() Record {
+	return it.page[it.pos]
+}
+
+//Domain returns the domain of the records, as returned with the last page loaded
+func (it *RecordIterator) Domain() RecordDomain {
+	return it.domain
+}
+
+//Err returns the error that stopped the iteration
+func (it *RecordIterator) Err() error {
+	return it.err
+}
+
+//Iterate returns an iterator over all records of a domain
+//opt.Offset is the start position, opt.Length is the page size (DefaultPageSize by default)
+func (p *RecordAPI) Iterate(domain string, opt ...RecordListOpt) *RecordIterator {
+	return p.IterateContext(context.Background(), domain, opt...)
+}
+
+//IterateContext is like Iterate but carries ctx for cancellation and deadlines
+func (p *RecordAPI) IterateContext(ctx context.Context, domain string, opt ...RecordListOpt) *RecordIterator {
+	return IterateRecords(ctx, p, domain, opt...)
+}
+
+//IterateRecords returns an iterator over all records of a domain served by s
+func IterateRecords(ctx context.Context, s RecordService, domain string, opt ...RecordListOpt) *RecordIterator {
+	var o RecordListOpt
+	if len(opt) > 0 {
+		o = opt[0]
+	}
+	it := &RecordIterator{}
+	it.pager = newPager(ctx, o.Offset, o.Length, func(ctx context.Context, offset, length int) (int, error) {
+		o.Offset, o.Length = offset, length
+		list, err := s.ListContext(ctx, domain, o)
+		it.page = list.Records
+		if err == nil {
+			it.domain = list.Domain
+		}
+		return len(list.Records), err
+	})
+	return it
+}
+
+//DomainIterator pages through the domains
+type DomainIterator struct {
+	pager
+	page []Domain
+}
+
+//Next advances to the next domain, it returns false when there are no more domains or on error
+func (it *DomainIterator) Next() bool {
+	return it.next()
+}
+
+//Domain returns the current domain
+func (it *DomainIterator) Domain() Domain {
+	return it.page[it.pos]
+}
+
+//Err returns the error that stopped the iteration
+func (it *DomainIterator) Err() error {
+	return it.err
+}
+
+//Iterate returns an iterator over all domains matching opt
+//opt.Offset is the start position, opt.Length is the page size (DefaultPageSize by default)
+func (p *DomainAPI) Iterate(opt ...DomainListOpt) *DomainIterator {
+	return p.IterateContext(context.Background(), opt...)
+}
+
+//IterateContext is like Iterate but carries ctx for cancellation and deadlines
+func (p *DomainAPI) IterateContext(ctx context.Context, opt ...DomainListOpt) *DomainIterator {
+	return IterateDomains(ctx, p, opt...)
+}
+
+//IterateDomains returns an iterator over all domains matching opt served by s
+func IterateDomains(ctx context.Context, s DomainService, opt ...DomainListOpt) *DomainIterator {
+	var o DomainListOpt
+	if len(opt) > 0 {
+		o = opt[0]
+	}
+	it := &DomainIterator{}
+	it.pager = newPager(ctx, o.Offset, o.Length, func(ctx context.Context, offset, length int) (int, error) {
+		o.Offset, o.Length = offset, length
+		list, err := s.ListContext(ctx, o)
+		it.page = list
+		return len(list), err
+	})
+	return it
+}
+
+//LogIterator pages through the log entries of a domain
+type LogIterator struct {
+	pager
+	page []string
+}
+
+//Next advances to the next log entry, it returns false when there are no more entries or on error
+func (it *LogIterator) Next() bool {
+	return it.next()
+}
+
+//Entry returns the current log entry
+func (it *LogIterator) Entry() string {
+	return it.page[it.pos]
+}
+
+//Err returns the error that stopped the iteration
+func (it *LogIterator) Err() error {
+	return it.err
+}
+
+//IterateLog returns an iterator over all log entries of a domain
+//opt.Offset is the start position, opt.Length is the page size (DefaultPageSize by default)
+func (p *DomainAPI) IterateLog(domain string, opt ...DomainLogOpt) *LogIterator {
+	return p.IterateLogContext(context.Background(), domain, opt...)
+}
+
+//IterateLogContext is like IterateLog but carries ctx for cancellation and deadlines
+func (p *DomainAPI) IterateLogContext(ctx context.Context, domain string, opt ...DomainLogOpt) *LogIterator {
+	return IterateDomainLog(ctx, p, domain, opt...)
+}
+
+//IterateDomainLog returns an iterator over all log entries of a domain served by s
+func IterateDomainLog(ctx context.Context, s DomainService, domain string, opt ...DomainLogOpt) *LogIterator {
+	var o DomainLogOpt
+	if len(opt) > 0 {
+		o = opt[0]
+	}
+	it := &LogIterator{}
+	it.pager = newPager(ctx, o.Offset, o.Length, func(ctx context.Context, offset, length int) (int, error) {
+		o.Offset, o.Length = offset, length
+		log, err := s.LogContext(ctx, domain, o)
+		it.page = log
+		return len(log), err
+	})
+	return it
+}
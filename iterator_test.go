@@ -0,0 +1,61 @@
+package dnspod_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/bigemon/dnspod"
+)
+
+//countCalls is a Middleware counting the calls of each endpoint
+func countCalls(calls map[string]int) dnspod.Middleware {
+	return func(next dnspod.Handler) dnspod.Handler {
+		return func(ctx context.Context, req *dnspod.Request) (*dnspod.Response, error) {
+			calls[req.Endpoint]++
+			return next(ctx, req)
+		}
+	}
+}
+
+func TestIterate(t *testing.T) {
+	s := newServer(t)
+	s.AddDomain("example.com")
+	for i := 0; i < 23; i++ {
+		s.AddRecord("example.com", dnspod.Record{Name: fmt.Sprintf("h%d", i), Type: "A", Value: "192.0.2.1", Enabled: true})
+	}
+	calls := map[string]int{}
+	d := s.Dnspod(dnspod.WithMiddleware(countCalls(calls)))
+	it := d.Record.Iterate("example.com", dnspod.RecordListOpt{Length: 10})
+	seen := map[int64]bool{}
+	for it.Next() {
+		seen[it.Record().ID] = true
+	}
+	if err := it.Err(); err != nil {
+		t.Fatal(err)
+	}
+	//23 records and the 2 NS records, in 3 pages
+	if len(seen) != 25 || calls["Record.List"] != 3 {
+		t.Errorf("iterated %d records in %d calls, want 25 in 3", len(seen), calls["Record.List"])
+	}
+
+	//a full last page needs one more call to find the end
+	for i := 0; i < 5; i++ {
+		s.AddDomain(fmt.Sprintf("d%d.com", i))
+	}
+	calls["Domain.List"] = 0
+	n := 0
+	for dit := d.Domain.Iterate(dnspod.DomainListOpt{Length: 3}); dit.Next(); n++ {
+	}
+	if n != 6 || calls["Domain.List"] != 3 {
+		t.Errorf("iterated %d domains in %d calls, want 6 in 3", n, calls["Domain.List"])
+	}
+
+	//an error stops the iteration
+	s.FailNext("Record.List", -1, "login failed")
+	it = d.Record.Iterate("example.com")
+	if it.Next() || !errors.Is(it.Err(), dnspod.ErrAuth) {
+		t.Errorf("Err() = %v, want ErrAuth", it.Err())
+	}
+}
@@ -0,0 +1,200 @@
+package dnspod
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+//RecordLine is the line (线路) of a record, the resolvers a record answers to
+type RecordLine string
+
+const (
+	//LineDefault answers all the resolvers not matched by another line
+	LineDefault RecordLine = "默认"
+	//LineTelecom answers the China Telecom resolvers
+	LineTelecom RecordLine = "电信"
+	//LineUnicom answers the China Unicom resolvers
+	LineUnicom RecordLine = "联通"
+	//LineMobile answers the China Mobile resolvers
+	LineMobile RecordLine = "移动"
+	//LineOverseas answers the resolvers outside of mainland China
+	LineOverseas RecordLine = "境外"
+	//LineSearchEngine answers the search engine spiders
+	LineSearchEngine RecordLine = "搜索引擎"
+)
+
+//LineList is the lines available for a domain
+type LineList struct {
+	Lines []RecordLine
+	IDs   map[RecordLine]string //The line ID (record_line_id) of each line, such as "10=0" for 电信
+}
+
+//Contains reports whether the line is available
+func (p LineList) Contains(line RecordLine) bool {
+	for _, l := range p.Lines {
+		if l == line {
+			return true
+		}
+	}
+	return false
+}
+
+//LineError is returned by Record.Create/Modify when the line is not available for the domain
+type LineError struct {
+	Domain string
+	Grade  string
+	Line   RecordLine
+}
+
+//Error interface
+func (e *LineError) Error() string {
+	return fmt.Sprintf("dnspod: line %q is not available for %s (grade %s)", e.Line, e.Domain, e.Grade)
+}
+
+//Line used to get the lines available for a domain
+//domain: 		Domain name
+//grade:		The grade of the domain (Domain.Grade), it is looked up with Domain.Info when empty
+func (p *RecordAPI) Line(domain, grade string) (LineList, error) {
+	return p.LineContext(context.Background(), domain, grade)
+}
+
+//LineContext is like Line but carries ctx for cancellation and deadlines
+func (p *RecordAPI) LineContext(ctx context.Context, domain, grade string) (list LineList, err error) {
+	if grade == "" {
+		if grade, err = p.c.meta.grade(ctx, p.c, domain); err != nil {
+			return
+		}
+	}
+	var jsonRes struct {
+		Status  Status            `json:"status"`
+		Lines   []RecordLine      `json:"lines"`
+		LineIDs map[string]string `json:"line_ids"`
+	}
+	params := url.Values{}
+	params.Set("domain", domain)
+	params.Set("domain_grade", grade)
+	res, err := p.c.post(ctx, "Record.Line", params)
+	if err != nil {
+		return
+	}
+	if err = json.Unmarshal(res, &jsonRes); err != nil {
+		return
+	}
+	if jsonRes.Status.Code != 1 {
+		return list, newAPIError("Record.Line", params, jsonRes.Status)
+	}
+	list.Lines = jsonRes.Lines
+	list.IDs = map[RecordLine]string{}
+	for k, v := range jsonRes.LineIDs {
+		list.IDs[RecordLine(k)] = v
+	}
+	return list, nil
+}
+
+//checkLine rejects the lines which are not available for the domain, before Create/Modify.
+//The default line is always available, the others are checked with the cached Record.Line.
+//When the lookup fails the line is not checked, dnspod decides.
+func (p *RecordAPI) checkLine(ctx context.Context, domain string, line RecordLine) error {
+	if p.c.noPreflight || line == "" || line == LineDefault || p.c.meta.unchecked(domain) {
+		return nil
+	}
+	key := strings.ToLower(domain)
+	p.c.meta.mu.Lock()
+	list, ok := p.c.meta.lines[key]
+	p.c.meta.mu.Unlock()
+	if !ok {
+		grade, err := p.c.meta.grade(ctx, p.c, domain)
+		if err != nil {
+			p.c.meta.failed(domain, err)
+			return nil
+		}
+		if list, err = p.LineContext(ctx, domain, grade); err != nil {
+			p.c.meta.failed(domain, err)
+			return nil
+		}
+		p.c.meta.mu.Lock()
+		if p.c.meta.lines == nil {
+			p.c.meta.lines = map[string]LineList{}
+		}
+		p.c.meta.lines[key] = list
+		p.c.meta.mu.Unlock()
+	}
+	if !list.Contains(line) {
+		grade, _ := p.c.meta.grade(ctx, p.c, domain)
+		return &LineError{Domain: domain, Grade: grade, Line: line}
+	}
+	return nil
+}
+
+//domainMeta caches what the preflight checks know about the domains, keyed by the lower case name
+type domainMeta struct {
+	mu      sync.Mutex
+	grades  map[string]string
+	lines   map[string]LineList
+	types   map[string][]RType //Keyed by grade
+	refused map[string]bool    //The domains whose lookups dnspod refused, they are not checked
+}
+
+//failed records a failed lookup for the domain. When dnspod refused it (an *APIError,
+//such as a token without the permission of Domain.Info) the domain is not checked any more,
+//a network error is tried again by the next call.
+func (m *domainMeta) failed(domain string, err error) {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		return
+	}
+	m.mu.Lock()
+	if m.refused == nil {
+		m.refused = map[string]bool{}
+	}
+	m.refused[strings.ToLower(domain)] = true
+	m.mu.Unlock()
+}
+
+//unchecked reports whether the lookups of the domain were refused
+func (m *domainMeta) unchecked(domain string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.refused[strings.ToLower(domain)]
+}
+
+//grade returns the grade of the domain, looked up once with Domain.Info
+func (m *domainMeta) grade(ctx context.Context, c *client, domain string) (string, error) {
+	key := strings.ToLower(domain)
+	m.mu.Lock()
+	g, ok := m.grades[key]
+	m.mu.Unlock()
+	if ok {
+		return g, nil
+	}
+	info, err := (&DomainAPI{c: c}).InfoContext(ctx, domain)
+	if err != nil {
+		return "", err
+	}
+	m.mu.Lock()
+	if m.grades == nil {
+		m.grades = map[string]string{}
+	}
+	m.grades[key] = info.Grade
+	m.mu.Unlock()
+	return info.Grade, nil
+}
+
+//WithPreflight enables or disables the checks of Record.Create/Modify before calling dnspod,
+//enabled by default: the record type must be listed by Record.Type for the grade of the domain
+//and a line other than LineDefault must be listed by Record.Line.
+//The grade, types and lines of a domain are cached for the life of the client, so only the first
+//write of a domain costs extra calls, up to 3 of them: Domain.Info, Record.Type (once per grade)
+//and Record.Line (for a line other than LineDefault). They count against the rate limits.
+//When a lookup fails the check is skipped and dnspod decides, only a type or a line
+//missing from the list is rejected.
+func WithPreflight(enabled bool) Option {
+	return func(c *client) {
+		c.noPreflight = !enabled
+	}
+}
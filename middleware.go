@@ -0,0 +1,126 @@
+package dnspod
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/url"
+	"time"
+)
+
+//Request is an API call seen by a Middleware
+//Params never contains the login_token, it is added by the innermost Handler,
+//but it contains the passwords sent by User.ModifyPassword/ModifyEmail.
+type Request struct {
+	Endpoint string     //The API called, such as "Record.List"
+	Params   url.Values //The request params without login_token
+	Attempt  int        //1 for the first attempt, increased on each retry
+}
+
+//Response is the raw answer of an API call
+type Response struct {
+	StatusCode int //HTTP status code, 0 if no response was received
+	Body       []byte
+}
+
+//Status decodes the dnspod status from the body
+func (r *Response) Status() (s Status, ok bool) {
+	if r == nil {
+		return s, false
+	}
+	var jsonRes struct {
+		Status *Status `json:"status"`
+	}
+	if json.Unmarshal(r.Body, &jsonRes) != nil || jsonRes.Status == nil {
+		return s, false
+	}
+	return *jsonRes.Status, true
+}
+
+//Handler sends an API call
+type Handler func(ctx context.Context, req *Request) (*Response, error)
+
+//Middleware wraps a Handler to observe or change every attempt of every API call
+type Middleware func(next Handler) Handler
+
+//WithMiddleware appends middleware to the chain, the first one is the outermost
+func WithMiddleware(mw ...Middleware) Option {
+	return func(c *client) {
+		c.middleware = append(c.middleware, mw...)
+	}
+}
+
+//handler builds the middleware chain around send
+func (c *client) handler() Handler {
+	h := c.send
+	for i := len(c.middleware) - 1; i >= 0; i-- {
+		h = c.middleware[i](h)
+	}
+	return h
+}
+
+//LoggingMiddleware logs each attempt with its endpoint, latency, HTTP status and dnspod status
+func LoggingMiddleware(logger *slog.Logger) Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, req *Request) (*Response, error) {
+			start := time.Now()
+			resp, err := next(ctx, req)
+			attrs := []slog.Attr{
+				slog.String("endpoint", req.Endpoint),
+				slog.Int("attempt", req.Attempt),
+				slog.Duration("latency", time.Since(start)),
+			}
+			if resp != nil {
+				attrs = append(attrs, slog.Int("http_status", resp.StatusCode))
+			}
+			level := slog.LevelInfo
+			if s, ok := resp.Status(); ok {
+				attrs = append(attrs, slog.Int("code", s.Code), slog.String("message", s.Message))
+				if s.Code != 1 {
+					level = slog.LevelWarn
+				}
+			}
+			if err != nil {
+				attrs = append(attrs, slog.String("error", err.Error()))
+				level = slog.LevelError
+			}
+			logger.LogAttrs(ctx, level, "dnspod call", attrs...)
+			return resp, err
+		}
+	}
+}
+
+//Tracer starts spans, implement it to plug in a tracing library such as OpenTelemetry
+type Tracer interface {
+	Start(ctx context.Context, name string) (context.Context, Span)
+}
+
+//Span is a traced operation started by a Tracer
+type Span interface {
+	SetAttribute(key string, value interface{})
+	RecordError(err error)
+	End()
+}
+
+//TracingMiddleware starts a span named "dnspod <Endpoint>" for each attempt
+func TracingMiddleware(t Tracer) Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, req *Request) (*Response, error) {
+			ctx, span := t.Start(ctx, "dnspod "+req.Endpoint)
+			defer span.End()
+			span.SetAttribute("dnspod.endpoint", req.Endpoint)
+			span.SetAttribute("dnspod.attempt", req.Attempt)
+			resp, err := next(ctx, req)
+			if resp != nil && resp.StatusCode != 0 {
+				span.SetAttribute("http.status_code", resp.StatusCode)
+			}
+			if s, ok := resp.Status(); ok {
+				span.SetAttribute("dnspod.status_code", s.Code)
+			}
+			if err != nil {
+				span.RecordError(err)
+			}
+			return resp, err
+		}
+	}
+}
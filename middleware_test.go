@@ -0,0 +1,120 @@
+package dnspod_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/bigemon/dnspod"
+	"github.com/bigemon/dnspod/dnspodtest"
+)
+
+func TestLoggingMiddleware(t *testing.T) {
+	s := dnspodtest.NewServer("1234,secret-token")
+	defer s.Close()
+	s.SetPassword("old-secret")
+	s.AddDomain("example.com")
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	d := s.Dnspod(dnspod.WithMiddleware(dnspod.LoggingMiddleware(logger)))
+
+	if _, err := d.Record.List("example.com"); err != nil {
+		t.Fatal(err)
+	}
+	d.User.ModifyPassword("wrong-secret", "new-secret")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("%d log lines, want 2:\n%s", len(lines), buf.String())
+	}
+	var ok, failed map[string]interface{}
+	json.Unmarshal([]byte(lines[0]), &ok)
+	json.Unmarshal([]byte(lines[1]), &failed)
+	for k, want := range map[string]interface{}{
+		"level": "INFO", "msg": "dnspod call", "endpoint": "Record.List",
+		"attempt": 1.0, "http_status": 200.0, "code": 1.0,
+	} {
+		if ok[k] != want {
+			t.Errorf("%s = %v, want %v", k, ok[k], want)
+		}
+	}
+	if _, found := ok["latency"]; !found {
+		t.Error("latency is not logged")
+	}
+	if failed["level"] != "WARN" || failed["endpoint"] != "Userpasswd.Modify" || failed["code"] != 6.0 {
+		t.Errorf("failed call logged as %v", failed)
+	}
+	for _, secret := range []string{"secret-token", "old-secret", "wrong-secret", "new-secret"} {
+		if strings.Contains(buf.String(), secret) {
+			t.Errorf("the log contains %q", secret)
+		}
+	}
+}
+
+//recordingTracer keeps the attributes of the spans it started
+type recordingTracer struct {
+	mu    sync.Mutex
+	spans []*recordingSpan
+}
+
+type recordingSpan struct {
+	name  string
+	attrs map[string]interface{}
+	errs  []error
+	ended bool
+}
+
+func (t *recordingTracer) Start(ctx context.Context, name string) (context.Context, dnspod.Span) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	s := &recordingSpan{name: name, attrs: map[string]interface{}{}}
+	t.spans = append(t.spans, s)
+	return ctx, s
+}
+
+func (s *recordingSpan) SetAttribute(key string, value interface{}) { s.attrs[key] = value }
+func (s *recordingSpan) RecordError(err error)                      { s.errs = append(s.errs, err) }
+func (s *recordingSpan) End()                                       { s.ended = true }
+
+func TestTracingMiddleware(t *testing.T) {
+	s := dnspodtest.NewServer("1234,secret-token")
+	defer s.Close()
+	s.SetPassword("old-secret")
+	tracer := &recordingTracer{}
+	d := s.Dnspod(dnspod.WithMiddleware(dnspod.TracingMiddleware(tracer)))
+
+	d.Domain.Create("example.com")
+	d.User.ModifyPassword("wrong-secret", "new-secret")
+
+	if len(tracer.spans) != 2 {
+		t.Fatalf("%d spans, want 2", len(tracer.spans))
+	}
+	span := tracer.spans[0]
+	if span.name != "dnspod Domain.Create" || !span.ended {
+		t.Errorf("span %q ended %v", span.name, span.ended)
+	}
+	for k, want := range map[string]interface{}{
+		"dnspod.endpoint": "Domain.Create", "dnspod.attempt": 1,
+		"http.status_code": 200, "dnspod.status_code": 1,
+	} {
+		if span.attrs[k] != want {
+			t.Errorf("%s = %v, want %v", k, span.attrs[k], want)
+		}
+	}
+	if code := tracer.spans[1].attrs["dnspod.status_code"]; code != 6 {
+		t.Errorf("dnspod.status_code of the failed call = %v, want 6", code)
+	}
+	for _, span := range tracer.spans {
+		all := fmt.Sprint(span.name, span.attrs, span.errs)
+		for _, secret := range []string{"secret-token", "old-secret", "wrong-secret", "new-secret"} {
+			if strings.Contains(all, secret) {
+				t.Errorf("span %q contains %q", span.name, secret)
+			}
+		}
+	}
+}
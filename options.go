@@ -0,0 +1,53 @@
+package dnspod
+
+import (
+	"net/http"
+	"time"
+)
+
+const (
+	//DefaultBaseURL is the API endpoint of dnspod.cn
+	DefaultBaseURL = "https://dnsapi.cn"
+	//IntlBaseURL is the API endpoint of the international dnspod.com
+	IntlBaseURL = "https://api.dnspod.com"
+	//DefaultUserAgent is the User-Agent sent with every request
+	DefaultUserAgent = "Go DDNS/1.0.0 (bigemon@foxmail.com)"
+)
+
+//Option is the optional arg of NewDnspod
+type Option func(*client)
+
+//WithBaseURL sets the API endpoint, such as IntlBaseURL, a proxy or a local test server
+func WithBaseURL(baseURL string) Option {
+	return func(c *client) {
+		c.baseURL = baseURL
+	}
+}
+
+//WithHTTPClient sets the http.Client used to send requests
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *client) {
+		if hc != nil {
+			c.httpClient = hc
+		}
+	}
+}
+
+//WithUserAgent sets the User-Agent header
+//dnspod requires it to identify the caller, an empty value will be ignored
+func WithUserAgent(ua string) Option {
+	return func(c *client) {
+		if ua != "" {
+			c.userAgent = ua
+		}
+	}
+}
+
+//WithTimeout sets the timeout of each HTTP attempt, 0 means no timeout.
+//A retried call can take up to RetryPolicy.MaxAttempts times d plus the backoff delays,
+//use a ctx deadline to bound the whole call. It also bounds the WAN IP detection of MyWANIP/MyWANIPv6.
+func WithTimeout(d time.Duration) Option {
+	return func(c *client) {
+		c.timeout = d
+	}
+}
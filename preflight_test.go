@@ -0,0 +1,92 @@
+package dnspod_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/bigemon/dnspod"
+)
+
+func TestLinePreflight(t *testing.T) {
+	s := newServer(t)
+	s.AddDomain("example.com")
+	calls := map[string]int{}
+	d := s.Dnspod(dnspod.WithMiddleware(countCalls(calls)))
+
+	//the default line is never looked up
+	if _, err := d.Record.Create("example.com", dnspod.RTypeA, "192.0.2.1", dnspod.RecordOpt{RecordLine: dnspod.LineDefault}); err != nil {
+		t.Fatal(err)
+	}
+	if calls["Record.Line"] != 0 {
+		t.Errorf("Record.Line called %d times for the default line", calls["Record.Line"])
+	}
+
+	_, err := d.Record.Create("example.com", dnspod.RTypeA, "192.0.2.1", dnspod.RecordOpt{RecordLine: "火星"})
+	var lineErr *dnspod.LineError
+	if !errors.As(err, &lineErr) || lineErr.Line != "火星" || lineErr.Domain != "example.com" || lineErr.Grade != "DP_Free" {
+		t.Errorf("err = %v, want a *LineError", err)
+	}
+	if calls["Record.Create"] != 1 {
+		t.Errorf("Record.Create called %d times, the invalid line must not be sent", calls["Record.Create"])
+	}
+	if err := d.Record.Modify("example.com", 1, dnspod.RTypeA, "192.0.2.1", dnspod.RecordOpt{RecordLine: "火星"}); !errors.As(err, &lineErr) {
+		t.Errorf("Modify: err = %v, want a *LineError", err)
+	}
+	if _, err := d.Record.Create("example.com", dnspod.RTypeA, "192.0.2.1", dnspod.RecordOpt{SubDomain: "tel", RecordLine: dnspod.LineTelecom}); err != nil {
+		t.Fatal(err)
+	}
+	//the lines and the grade are looked up once
+	if calls["Record.Line"] != 1 || calls["Domain.Info"] != 1 {
+		t.Errorf("calls = %v, want a single lookup", calls)
+	}
+
+	//without preflight dnspod rejects the line
+	_, err = s.Dnspod(dnspod.WithPreflight(false)).Record.Create("example.com", dnspod.RTypeA, "192.0.2.1", dnspod.RecordOpt{RecordLine: "火星"})
+	var apiErr *dnspod.APIError
+	if !errors.As(err, &apiErr) || apiErr.Code != 26 {
+		t.Errorf("without preflight: err = %v, want code 26", err)
+	}
+
+	//a failed lookup lets dnspod decide
+	s.AddDomain("example.org")
+	s.FailNext("Domain.Info", -3, "no permission")
+	if _, err := d.Record.Create("example.org", dnspod.RTypeA, "192.0.2.1", dnspod.RecordOpt{RecordLine: dnspod.LineTelecom}); err != nil {
+		t.Errorf("Create after a failed lookup: %v", err)
+	}
+}
+
+func TestTypePreflight(t *testing.T) {
+	s := newServer(t)
+	s.AddDomain("example.com")
+	s.SetGradeTypes("DP_Free", []string{"A", "CNAME", "MX", "TXT", "NS"})
+	calls := map[string]int{}
+	d := s.Dnspod(dnspod.WithMiddleware(countCalls(calls)))
+
+	_, err := d.Record.Create("example.com", dnspod.RTypeCAA, `0 issue "ca.example"`)
+	var typeErr *dnspod.TypeError
+	if !errors.As(err, &typeErr) || typeErr.Type != dnspod.RTypeCAA || typeErr.Grade != "DP_Free" {
+		t.Errorf("err = %v, want a *TypeError", err)
+	}
+	if err := d.Record.Modify("example.com", 1, dnspod.RTypeAAAA, "2001:db8::1"); !errors.As(err, &typeErr) {
+		t.Errorf("Modify: err = %v, want a *TypeError", err)
+	}
+	if _, err := d.Record.Create("example.com", dnspod.RTypeTXT, "hello"); err != nil {
+		t.Fatal(err)
+	}
+	//the types of a grade are looked up once, the rejected records are not sent
+	if calls["Record.Type"] != 1 || calls["Record.Create"] != 1 || calls["Record.Modify"] != 0 {
+		t.Errorf("calls = %v", calls)
+	}
+	if types, err := d.Record.Type("DP_Free"); err != nil || len(types) != 5 {
+		t.Errorf("Type = %v, %v", types, err)
+	}
+
+	//a failed lookup lets dnspod decide
+	s.AddDomain("example.org")
+	s.FailNext("Domain.Info", -3, "no permission")
+	_, err = d.Record.Create("example.org", dnspod.RTypeCAA, `0 issue "ca.example"`)
+	var apiErr *dnspod.APIError
+	if !errors.As(err, &apiErr) || apiErr.Code != 27 {
+		t.Errorf("Create after a failed lookup: err = %v, want code 27 from dnspod", err)
+	}
+}
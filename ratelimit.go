@@ -0,0 +1,94 @@
+package dnspod
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+//WithRateLimit limits the calls of a Dnspod instance to rate per second, with bursts of up to burst calls.
+//The limit is shared by Record, Domain and User, calls over the limit block until allowed or ctx is done.
+func WithRateLimit(rate float64, burst int) Option {
+	return func(c *client) {
+		c.limit = newBucket(rate, burst)
+	}
+}
+
+//WithEndpointRateLimit limits the calls of the specified API, such as "Record.Modify".
+//It applies on top of WithRateLimit, a call has to be allowed by both, so an endpoint limit
+//can only tighten the shared one: the calls of every API count against the account quota.
+func WithEndpointRateLimit(action string, rate float64, burst int) Option {
+	return func(c *client) {
+		if c.endpointLimits == nil {
+			c.endpointLimits = map[string]*bucket{}
+		}
+		c.endpointLimits[action] = newBucket(rate, burst)
+	}
+}
+
+//waitLimit blocks until the call of action is allowed by the rate limiters
+func (c *client) waitLimit(ctx context.Context, action string) error {
+	if err := c.endpointLimits[action].wait(ctx); err != nil {
+		return err
+	}
+	return c.limit.wait(ctx)
+}
+
+//bucket is a token bucket, a nil bucket allows everything
+type bucket struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newBucket(rate float64, burst int) *bucket {
+	if rate <= 0 {
+		return nil
+	}
+	if burst < 1 {
+		burst = 1
+	}
+	return &bucket{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+//wait takes a token, blocks until the token is available or ctx is done
+func (b *bucket) wait(ctx context.Context) error {
+	if b == nil {
+		return nil
+	}
+	b.mu.Lock()
+	now := time.Now()
+	b.tokens += now.Sub(b.last).Seconds() * b.rate
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+	b.last = now
+	b.tokens--
+	var d time.Duration
+	if b.tokens < 0 {
+		d = time.Duration(-b.tokens / b.rate * float64(time.Second))
+	}
+	b.mu.Unlock()
+	if d == 0 {
+		return nil
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		//give back the reserved token
+		b.mu.Lock()
+		b.tokens++
+		b.mu.Unlock()
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
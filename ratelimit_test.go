@@ -0,0 +1,85 @@
+package dnspod
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestBucket(t *testing.T) {
+	b := newBucket(100, 3)
+	ctx := context.Background()
+	start := time.Now()
+	//the burst is allowed at once, the next calls wait 10ms each
+	for i := 0; i < 5; i++ {
+		if err := b.wait(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if d := time.Since(start); d < 15*time.Millisecond || d > 500*time.Millisecond {
+		t.Errorf("5 calls at 100/s with a burst of 3 took %v, want about 20ms", d)
+	}
+	if newBucket(0, 1) != nil {
+		t.Error("a rate of 0 should not limit")
+	}
+	var unlimited *bucket
+	if err := unlimited.wait(ctx); err != nil {
+		t.Errorf("nil bucket: %v", err)
+	}
+}
+
+func TestBucketContext(t *testing.T) {
+	b := newBucket(1, 1)
+	if err := b.wait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := b.wait(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err = %v, want context.DeadlineExceeded", err)
+	}
+	//the token reserved by the canceled call is given back
+	b.mu.Lock()
+	tokens := b.tokens
+	b.mu.Unlock()
+	if tokens < -0.1 {
+		t.Errorf("tokens = %v after a canceled wait, want about 0", tokens)
+	}
+}
+
+func TestEndpointRateLimit(t *testing.T) {
+	c := &client{}
+	WithRateLimit(1000, 3)(c)
+	WithEndpointRateLimit("Record.Modify", 1, 1)(c)
+	WithEndpointRateLimit("Record.List", 0, 0)(c)
+	ctx := context.Background()
+	if err := c.waitLimit(ctx, "Record.Modify"); err != nil {
+		t.Fatal(err)
+	}
+	//the endpoint limit is tighter than the shared one
+	short, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	if err := c.waitLimit(short, "Record.Modify"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("the second Record.Modify: err = %v, want context.DeadlineExceeded", err)
+	}
+
+	//a generous or disabled endpoint limit does not bypass the shared one
+	c = &client{}
+	WithRateLimit(1, 2)(c)
+	WithEndpointRateLimit("Record.Modify", 1000, 100)(c)
+	WithEndpointRateLimit("Record.List", 0, 0)(c)
+	for _, action := range []string{"Record.Modify", "Record.List"} {
+		if err := c.waitLimit(ctx, action); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, action := range []string{"Record.Modify", "Record.List", "Domain.List"} {
+		short, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+		err := c.waitLimit(short, action)
+		cancel()
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("%s over the shared limit: err = %v, want context.DeadlineExceeded", action, err)
+		}
+	}
+}
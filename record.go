@@ -1,9 +1,9 @@
 package dnspod
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
-	"net/http"
 	"net/url"
 	"strconv"
 )
@@ -38,12 +38,25 @@ const (
 	RTypeAAAA RType = "AAAA"
 	//RTypeSRV Records which computer provides which service. The format is the name, point, and type of the protocol, such as _XMPP-SERVER._TCP.
 	RTypeSRV RType = "SRV"
+	//RTypeCAA Certification Authority Authorization, the CAs allowed to issue certificates for the domain. The value is "flags tag \"value\"", such as: 0 issue "letsencrypt.org"
+	RTypeCAA RType = "CAA"
+	//RTypeSPF Sender Policy Framework, the value is the same as the TXT one, such as: v=spf1 include:spf.mail.qq.com ~all
+	RTypeSPF RType = "SPF"
+	//RTypeURL Explicit URL forwarding (显性URL), the browser is redirected to the URL of the value, such as: https://www.example.com
+	RTypeURL RType = "显性URL"
+	//RTypeHiddenURL Hidden URL forwarding (隐性URL), the URL of the value is shown in a frame and the address bar is kept
+	RTypeHiddenURL RType = "隐性URL"
+	//RTypeHTTPS Service binding of HTTPS, the value is "priority target params", such as: 1 . alpn="h3,h2"
+	RTypeHTTPS RType = "HTTPS"
+	//RTypeSVCB Service binding of the other protocols, the value has the format of HTTPS
+	RTypeSVCB RType = "SVCB"
 )
 
 //RecordInfo info of record
 type RecordInfo struct {
 	SubDomains  int `json:"sub_domains,string"`
 	RecordTotal int `json:"record_total,string"`
+	RecordsNum  int `json:"records_num,string"`
 }
 
 //RecordDomain domain info of record
@@ -78,52 +91,92 @@ type Record struct {
 	MX            int     `json:"mx,string"`
 }
 
-// //RecordList all records under a domain
-// type RecordList struct {
-// 	Status  Status       `json:"status"`
-// 	Domain  RecordDomain `json:"domain"`
-// 	Info    RecordInfo   `json:"info"`
-// 	Records []Record     `json:"records"`
-// }
+//RecordList all records under a domain
+type RecordList struct {
+	Domain  RecordDomain `json:"domain"`
+	Info    RecordInfo   `json:"info"`
+	Records []Record     `json:"records"`
+}
 
 //----------------------------------------------------------------------------------
 
 //RecordAPI packaged some dnspod record APIs
 type RecordAPI struct {
-	loginToken string
-	client     *http.Client
+	c *client
+}
+
+//RecordListOpt is Optional arg of Record.List
+type RecordListOpt struct {
+	SubDomain  string     `json:"sub_domain"`
+	RecordType RType      `json:"record_type"`
+	RecordLine RecordLine `json:"record_line"`
+	Keyword    string     `json:"keyword"`
+	Offset     int        `json:"offset"`
+	Length     int        `json:"length"`
 }
 
 //List is used to get a list of records for a specified domain
 //domain: The domain name you want to get the dns record
-func (p *RecordAPI) List(domain string) (list []Record, err error) {
-	// Get DNS record list (POST https://dnsapi.cn/Record.List)
+//opt:	  The optional filter criteria and pagination
+func (p *RecordAPI) List(domain string, opt ...RecordListOpt) (list RecordList, err error) {
+	return p.ListContext(context.Background(), domain, opt...)
+}
+
+//ListContext is like List but carries ctx for cancellation and deadlines
+func (p *RecordAPI) ListContext(ctx context.Context, domain string, opt ...RecordListOpt) (list RecordList, err error) {
+	// Get DNS record list (POST Record.List)
 	params := url.Values{}
-	params.Set("format", "json")
-	params.Set("login_token", p.loginToken)
 	params.Set("domain", domain)
-	res, err := simpleHTTP(p.client, "POST", "https://dnsapi.cn/Record.List", params)
+	var o RecordListOpt
+	if len(opt) > 0 {
+		o = opt[0]
+	}
+	if o.SubDomain != "" {
+		params.Set("sub_domain", o.SubDomain)
+	}
+	if o.RecordType != "" {
+		params.Set("record_type", string(o.RecordType))
+	}
+	if o.RecordLine != "" {
+		params.Set("record_line", string(o.RecordLine))
+	}
+	if o.Keyword != "" {
+		params.Set("keyword", o.Keyword)
+	}
+	if o.Length != 0 {
+		params.Set("length", strconv.Itoa(o.Length))
+	}
+	if o.Offset != 0 {
+		params.Set("offset", strconv.Itoa(o.Offset))
+	}
+
+	res, err := p.c.post(ctx, "Record.List", params)
 	if err != nil {
 		return
 	}
 	var jsonRes struct {
-		Status  Status   `json:"status"`
-		Records []Record `json:"records"`
+		Status Status `json:"status"`
+		RecordList
 	}
-	if err = json.Unmarshal(res, &list); err != nil {
+	if err = json.Unmarshal(res, &jsonRes); err != nil {
 		return
 	}
+	//code 10: there is no record matching the criteria
+	if jsonRes.Status.Code == 10 {
+		return jsonRes.RecordList, nil
+	}
 	if jsonRes.Status.Code != 1 {
-		return list, errors.New(jsonRes.Status.Message)
+		return list, newAPIError("Record.List", params, jsonRes.Status)
 	}
-	return jsonRes.Records, nil
+	return jsonRes.RecordList, nil
 }
 
 //DDNSOpt Opt arg struct
 type DDNSOpt struct {
-	SubDomain  string //The default value is "@"
-	RecordLine string //The default value is "默认"
-	Value      string //The default value is you wan ip
+	SubDomain  string     //The default value is "@"
+	RecordLine RecordLine //The default value is "默认"
+	Value      string     //The default value is you wan ip, required when RecordType is AAAA or with NewTencentCloud
+	RecordType RType      //The default value is "A", Record.Ddns only updates A records, other types are updated with Record.Modify
 }
 
 //DDNS used to update the specified DDNS record
@@ -131,18 +184,24 @@ type DDNSOpt struct {
 //domain: 		The domain name you want to get the dns record
 //opt:			The other optional arg
 func (p *RecordAPI) DDNS(domain string, recordID int64, opt ...DDNSOpt) (err error) {
+	return p.DDNSContext(context.Background(), domain, recordID, opt...)
+}
+
+//DDNSContext is like DDNS but carries ctx for cancellation and deadlines
+func (p *RecordAPI) DDNSContext(ctx context.Context, domain string, recordID int64, opt ...DDNSOpt) (err error) {
+	if len(opt) > 0 && opt[0].RecordType != "" && opt[0].RecordType != RTypeA {
+		return p.ddnsModify(ctx, domain, recordID, opt[0])
+	}
 	var jsonRes struct {
 		Status Status `json:"status"`
 	}
 	params := url.Values{}
 	params.Set("record_id", strconv.FormatInt(recordID, 10))
 	params.Set("domain", domain)
-	params.Set("login_token", p.loginToken)
-	params.Set("format", "json")
 	if len(opt) > 0 && opt[0].RecordLine != "" {
-		params.Set("record_line", opt[0].RecordLine)
+		params.Set("record_line", string(opt[0].RecordLine))
 	} else {
-		params.Set("record_line", "默认")
+		params.Set("record_line", string(LineDefault))
 	}
 	if len(opt) > 0 && opt[0].Value != "" {
 		params.Set("value", opt[0].Value)
@@ -151,7 +210,7 @@ func (p *RecordAPI) DDNS(domain string, recordID int64, opt ...DDNSOpt) (err err
 		params.Set("sub_domain", opt[0].SubDomain)
 	}
 
-	res, err := simpleHTTP(p.client, "POST", "https://dnsapi.cn/Record.Ddns", params)
+	res, err := p.c.post(ctx, "Record.Ddns", params)
 	if err != nil {
 		return
 	}
@@ -160,19 +219,43 @@ func (p *RecordAPI) DDNS(domain string, recordID int64, opt ...DDNSOpt) (err err
 		return
 	}
 	if jsonRes.Status.Code != 1 {
-		return errors.New(jsonRes.Status.Message)
+		return newAPIError("Record.Ddns", params, jsonRes.Status)
 	}
 	return nil
 }
 
+//ddnsModify updates the value of a non-A record, keeping its TTL, weight and status
+func (p *RecordAPI) ddnsModify(ctx context.Context, domain string, recordID int64, o DDNSOpt) (err error) {
+	if o.Value == "" {
+		return errors.New("Need to set up opt.Value")
+	}
+	r, err := p.InfoContext(ctx, domain, recordID)
+	if err != nil {
+		return
+	}
+	if o.SubDomain == "" {
+		o.SubDomain = r.Name
+	}
+	if o.RecordLine == "" {
+		o.RecordLine = RecordLine(r.Line)
+	}
+	return p.ModifyContext(ctx, domain, recordID, o.RecordType, o.Value, RecordOpt{
+		SubDomain:  o.SubDomain,
+		RecordLine: o.RecordLine,
+		Disable:    !bool(r.Enabled),
+		TTL:        r.TTL,
+		Weight:     r.Weight,
+	})
+}
+
 //RecordOpt Opt arg struct
 type RecordOpt struct {
-	SubDomain  string //The default value is "@"
-	RecordLine string //The default value is "默认"
-	Disable    bool   //If the incoming true, parsing does not take effect.
-	MX         int    //MX priority, valid when the record type is MX, range 1-20.
-	TTL        int    //Range 1-604800, different levels of domain names have different minimum values
-	Weight     int    //Range 0-100, Available only in the Enterprise VIP domain, 0 is used to shut down
+	SubDomain  string     //The default value is "@"
+	RecordLine RecordLine //The default value is "默认"
+	Disable    bool       //If the incoming true, parsing does not take effect.
+	MX         int        //MX priority, valid when the record type is MX, range 1-20.
+	TTL        int        //Range 1-604800, different levels of domain names have different minimum values
+	Weight     int        //Range 0-100, Available only in the Enterprise VIP domain, 0 is used to shut down
 }
 
 //Create used to create a DNS record
@@ -181,13 +264,15 @@ type RecordOpt struct {
 //value: 		The value of the record(ip/mx/url...)
 //opt:			The other optional arg
 func (p *RecordAPI) Create(domain string, recordType RType, value string, opt ...RecordOpt) (id int64, err error) {
+	return p.CreateContext(context.Background(), domain, recordType, value, opt...)
+}
+
+//CreateContext is like Create but carries ctx for cancellation and deadlines
+func (p *RecordAPI) CreateContext(ctx context.Context, domain string, recordType RType, value string, opt ...RecordOpt) (id int64, err error) {
 	if recordType == "MX" && (len(opt) == 0 || opt[0].MX == 0) {
 		return 0, errors.New("Need to set up opt.MX")
 	}
 	params := url.Values{}
-	params.Set("login_token", p.loginToken)
-	params.Set("format", "json")
-
 	params.Set("domain", domain)
 	params.Set("record_type", string(recordType))
 	params.Set("value", value)
@@ -195,10 +280,16 @@ func (p *RecordAPI) Create(domain string, recordType RType, value string, opt ..
 	if len(opt) > 0 {
 		o = opt[0]
 	}
+	if err = p.checkType(ctx, domain, recordType); err != nil {
+		return
+	}
+	if err = p.checkLine(ctx, domain, o.RecordLine); err != nil {
+		return
+	}
 	if o.RecordLine == "" {
-		params.Set("record_line", "默认")
+		params.Set("record_line", string(LineDefault))
 	} else {
-		params.Set("record_line", o.RecordLine)
+		params.Set("record_line", string(o.RecordLine))
 	}
 	if o.Disable {
 		params.Set("status", "disable")
@@ -218,7 +309,7 @@ func (p *RecordAPI) Create(domain string, recordType RType, value string, opt ..
 		params.Set("sub_domain", o.SubDomain)
 	}
 
-	res, err := simpleHTTP(p.client, "POST", "https://dnsapi.cn/Record.Create", params)
+	res, err := p.c.post(ctx, "Record.Create", params)
 	if err != nil {
 		return
 	}
@@ -230,7 +321,7 @@ func (p *RecordAPI) Create(domain string, recordType RType, value string, opt ..
 		return
 	}
 	if jsonRes.Status.Code != 1 {
-		return 0, errors.New(jsonRes.Status.Message)
+		return 0, newAPIError("Record.Create", params, jsonRes.Status)
 	}
 	return jsonRes.Record.ID, nil
 }
@@ -242,13 +333,15 @@ func (p *RecordAPI) Create(domain string, recordType RType, value string, opt ..
 //value: 		The value of the record(ip/mx/url...)
 //opt:			The other optional arg
 func (p *RecordAPI) Modify(domain string, recordID int64, recordType RType, value string, opt ...RecordOpt) (err error) {
+	return p.ModifyContext(context.Background(), domain, recordID, recordType, value, opt...)
+}
+
+//ModifyContext is like Modify but carries ctx for cancellation and deadlines
+func (p *RecordAPI) ModifyContext(ctx context.Context, domain string, recordID int64, recordType RType, value string, opt ...RecordOpt) (err error) {
 	if recordType == "MX" && (len(opt) == 0 || opt[0].MX == 0) {
 		return errors.New("Need to set up opt.MX")
 	}
 	params := url.Values{}
-	params.Set("login_token", p.loginToken)
-	params.Set("format", "json")
-
 	params.Set("domain", domain)
 	params.Set("record_id", strconv.FormatInt(recordID, 10))
 	params.Set("record_type", string(recordType))
@@ -257,10 +350,16 @@ func (p *RecordAPI) Modify(domain string, recordID int64, recordType RType, valu
 	if len(opt) > 0 {
 		o = opt[0]
 	}
+	if err = p.checkType(ctx, domain, recordType); err != nil {
+		return
+	}
+	if err = p.checkLine(ctx, domain, o.RecordLine); err != nil {
+		return
+	}
 	if o.RecordLine == "" {
-		params.Set("record_line", "默认")
+		params.Set("record_line", string(LineDefault))
 	} else {
-		params.Set("record_line", o.RecordLine)
+		params.Set("record_line", string(o.RecordLine))
 	}
 	if o.Disable {
 		params.Set("status", "disable")
@@ -280,7 +379,7 @@ func (p *RecordAPI) Modify(domain string, recordID int64, recordType RType, valu
 		params.Set("sub_domain", o.SubDomain)
 	}
 
-	res, err := simpleHTTP(p.client, "POST", "https://dnsapi.cn/Record.Modify", params)
+	res, err := p.c.post(ctx, "Record.Modify", params)
 	if err != nil {
 		return
 	}
@@ -291,7 +390,7 @@ func (p *RecordAPI) Modify(domain string, recordID int64, recordType RType, valu
 		return
 	}
 	if jsonRes.Status.Code != 1 {
-		return errors.New(jsonRes.Status.Message)
+		return newAPIError("Record.Modify", params, jsonRes.Status)
 	}
 	return nil
 }
@@ -300,14 +399,16 @@ func (p *RecordAPI) Modify(domain string, recordID int64, recordType RType, valu
 //domain: 		Domain name
 //recordID:		The specified record ID that you want to remove
 func (p *RecordAPI) Remove(domain string, recordID int64) (err error) {
-	params := url.Values{}
-	params.Set("login_token", p.loginToken)
-	params.Set("format", "json")
+	return p.RemoveContext(context.Background(), domain, recordID)
+}
 
+//RemoveContext is like Remove but carries ctx for cancellation and deadlines
+func (p *RecordAPI) RemoveContext(ctx context.Context, domain string, recordID int64) (err error) {
+	params := url.Values{}
 	params.Set("domain", domain)
 	params.Set("record_id", strconv.FormatInt(recordID, 10))
 
-	res, err := simpleHTTP(p.client, "POST", "https://dnsapi.cn/Record.Remove", params)
+	res, err := p.c.post(ctx, "Record.Remove", params)
 	if err != nil {
 		return
 	}
@@ -318,7 +419,7 @@ func (p *RecordAPI) Remove(domain string, recordID int64) (err error) {
 		return
 	}
 	if jsonRes.Status.Code != 1 {
-		return errors.New(jsonRes.Status.Message)
+		return newAPIError("Record.Remove", params, jsonRes.Status)
 	}
 	return nil
 }
@@ -328,15 +429,17 @@ func (p *RecordAPI) Remove(domain string, recordID int64) (err error) {
 //recordID:		The specified record ID that you want to remove
 //remark:		The remark you want to set,if set to null, will clear the remark
 func (p *RecordAPI) Remark(domain string, recordID int64, remark string) (err error) {
-	params := url.Values{}
-	params.Set("login_token", p.loginToken)
-	params.Set("format", "json")
+	return p.RemarkContext(context.Background(), domain, recordID, remark)
+}
 
+//RemarkContext is like Remark but carries ctx for cancellation and deadlines
+func (p *RecordAPI) RemarkContext(ctx context.Context, domain string, recordID int64, remark string) (err error) {
+	params := url.Values{}
 	params.Set("domain", domain)
 	params.Set("record_id", strconv.FormatInt(recordID, 10))
 	params.Set("remark", remark)
 
-	res, err := simpleHTTP(p.client, "POST", "https://dnsapi.cn/Record.Remark", params)
+	res, err := p.c.post(ctx, "Record.Remark", params)
 	if err != nil {
 		return
 	}
@@ -347,7 +450,7 @@ func (p *RecordAPI) Remark(domain string, recordID int64, remark string) (err er
 		return
 	}
 	if jsonRes.Status.Code != 1 {
-		return errors.New(jsonRes.Status.Message)
+		return newAPIError("Record.Remark", params, jsonRes.Status)
 	}
 	return nil
 }
@@ -356,14 +459,16 @@ func (p *RecordAPI) Remark(domain string, recordID int64, remark string) (err er
 //domain: 		Domain name
 //recordID:		The specified record ID that you want to get information
 func (p *RecordAPI) Info(domain string, recordID int64) (r Record, err error) {
-	params := url.Values{}
-	params.Set("login_token", p.loginToken)
-	params.Set("format", "json")
+	return p.InfoContext(context.Background(), domain, recordID)
+}
 
+//InfoContext is like Info but carries ctx for cancellation and deadlines
+func (p *RecordAPI) InfoContext(ctx context.Context, domain string, recordID int64) (r Record, err error) {
+	params := url.Values{}
 	params.Set("domain", domain)
 	params.Set("record_id", strconv.FormatInt(recordID, 10))
 
-	res, err := simpleHTTP(p.client, "POST", "https://dnsapi.cn/Record.Info", params)
+	res, err := p.c.post(ctx, "Record.Info", params)
 	if err != nil {
 		return
 	}
@@ -375,7 +480,7 @@ func (p *RecordAPI) Info(domain string, recordID int64) (r Record, err error) {
 		return
 	}
 	if jsonRes.Status.Code != 1 {
-		return r, errors.New(jsonRes.Status.Message)
+		return r, newAPIError("Record.Info", params, jsonRes.Status)
 	}
 	return jsonRes.Record, nil
 }
@@ -385,15 +490,17 @@ func (p *RecordAPI) Info(domain string, recordID int64) (r Record, err error) {
 //recordID:		The specified record ID that you want to get information
 //enable:		Status of the record to set, if incoming false, parsing does not take effect.
 func (p *RecordAPI) Status(domain string, recordID int64, enable Enable) (err error) {
-	params := url.Values{}
-	params.Set("login_token", p.loginToken)
-	params.Set("format", "json")
+	return p.StatusContext(context.Background(), domain, recordID, enable)
+}
 
+//StatusContext is like Status but carries ctx for cancellation and deadlines
+func (p *RecordAPI) StatusContext(ctx context.Context, domain string, recordID int64, enable Enable) (err error) {
+	params := url.Values{}
 	params.Set("domain", domain)
 	params.Set("record_id", strconv.FormatInt(recordID, 10))
 	params.Set("status", enable.String())
 
-	res, err := simpleHTTP(p.client, "POST", "https://dnsapi.cn/Record.Status", params)
+	res, err := p.c.post(ctx, "Record.Status", params)
 	if err != nil {
 		return
 	}
@@ -404,7 +511,7 @@ func (p *RecordAPI) Status(domain string, recordID int64, enable Enable) (err er
 		return
 	}
 	if jsonRes.Status.Code != 1 {
-		return errors.New(jsonRes.Status.Message)
+		return newAPIError("Record.Status", params, jsonRes.Status)
 	}
 	return nil
 }
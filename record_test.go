@@ -0,0 +1,141 @@
+package dnspod_test
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/bigemon/dnspod"
+)
+
+func TestDDNSAAAA(t *testing.T) {
+	s := newServer(t)
+	s.AddDomain("example.com")
+	d := s.Dnspod()
+	id, err := d.Record.Create("example.com", dnspod.RTypeAAAA, "2400:3200::1", dnspod.RecordOpt{
+		SubDomain:  "home",
+		RecordLine: dnspod.LineTelecom,
+		TTL:        3600,
+		Weight:     20,
+		Disable:    true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Record.DDNS("example.com", id, dnspod.DDNSOpt{RecordType: dnspod.RTypeAAAA}); err == nil {
+		t.Error("DDNS of an AAAA record without opt.Value succeeded")
+	}
+	if err := d.Record.DDNS("example.com", id, dnspod.DDNSOpt{RecordType: dnspod.RTypeAAAA, Value: "2400:3200::2"}); err != nil {
+		t.Fatal(err)
+	}
+	r, err := d.Record.Info("example.com", id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Value != "2400:3200::2" || r.Type != "AAAA" || r.Name != "home" || r.Line != string(dnspod.LineTelecom) ||
+		r.TTL != 3600 || r.Weight != 20 || r.Enabled {
+		t.Errorf("Info after DDNS = %+v", r)
+	}
+}
+
+func TestExport(t *testing.T) {
+	s := newServer(t)
+	s.AddDomain("example.com")
+	for i := 0; i < dnspod.DefaultPageSize+20; i++ {
+		s.AddRecord("example.com", dnspod.Record{Name: fmt.Sprintf("h%d", i), Type: "A", Value: "192.0.2.1", Enabled: true})
+	}
+	calls := map[string]int{}
+	d := s.Dnspod(dnspod.WithMiddleware(countCalls(calls)))
+	var b bytes.Buffer
+	if err := d.Record.Export(&b, "example.com"); err != nil {
+		t.Fatal(err)
+	}
+	if calls["Record.List"] != 2 {
+		t.Errorf("%d calls of Record.List, want 2", calls["Record.List"])
+	}
+	zone := b.String()
+	for _, want := range []string{"$ORIGIN example.com.\n", "@\tIN\tSOA\tf1g1ns1.dnspod.net. ", "h0\t600\tIN\tA\t192.0.2.1\n", fmt.Sprintf("h%d\t", dnspod.DefaultPageSize+19)} {
+		if !strings.Contains(zone, want) {
+			t.Errorf("the zone has no %q:\n%s", want, zone)
+		}
+	}
+
+	s.FailNext("Record.List", -1, "login failed")
+	if err := d.Record.Export(&b, "example.com"); err == nil {
+		t.Error("Export succeeded after a failed Record.List")
+	}
+}
+
+func TestImport(t *testing.T) {
+	zone := `$ORIGIN example.com.
+$TTL 600
+www	IN	A	192.0.2.1
+www	IN	A	192.0.2.2
+blog	IN	A	192.0.2.3
+shop	IN	CNAME	shops.example.net.
+new	IN	TXT	"hello"
+mail	IN	MX	50 mx.example.com.
+`
+	s := newServer(t)
+	s.AddDomain("example.com")
+	s.AddRecord("example.com", dnspod.Record{Name: "www", Type: "A", Value: "192.0.2.1", Enabled: true})
+	s.AddRecord("example.com", dnspod.Record{Name: "blog", Type: "CNAME", Value: "blogs.example.net.", Enabled: true})
+	s.AddRecord("example.com", dnspod.Record{Name: "shop", Type: "TXT", Value: "verify", Enabled: true})
+	d := s.Dnspod()
+
+	res, err := d.Record.Import("example.com", strings.NewReader(zone), dnspod.ZoneImportOpt{DryRun: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var creates []string
+	for _, c := range res.Plan.Changes {
+		if c.Kind != dnspod.ChangeCreate {
+			t.Errorf("%s in the import plan", c)
+		}
+		creates = append(creates, c.Desired.Name+" "+c.Desired.Value)
+	}
+	if got := strings.Join(creates, ", "); got != "new hello, www 192.0.2.2" {
+		t.Errorf("creates = %s", got)
+	}
+	//the records conflicting with a live CNAME are reported like the invalid ones
+	reasons := map[int]string{
+		5: "conflicts with the live CNAME blogs.example.net.",
+		6: "CNAME conflicts with the live TXT verify",
+		8: "MX priority 50 is out of range 1-20",
+	}
+	if len(res.Skipped) != len(reasons) {
+		t.Fatalf("skipped = %v", res.Skipped)
+	}
+	for _, sk := range res.Skipped {
+		if reasons[sk.Line] != sk.Reason {
+			t.Errorf("line %d skipped for %q, want %q", sk.Line, sk.Reason, reasons[sk.Line])
+		}
+	}
+	if len(s.Records("example.com")) != 5 {
+		t.Error("DryRun changed the records")
+	}
+
+	if _, err := d.Record.Import("example.com", strings.NewReader(zone)); err != nil {
+		t.Fatal(err)
+	}
+	if len(s.Records("example.com")) != 7 {
+		t.Errorf("records after Import = %+v", s.Records("example.com"))
+	}
+
+	//Replace modifies the live CNAME, the A record still can not be added beside it
+	zone = "blog 600 IN CNAME blogs2.example.net.\nblog 600 IN A 192.0.2.3\n"
+	res, err = d.Record.Import("example.com", strings.NewReader(zone), dnspod.ZoneImportOpt{Replace: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Plan.Changes) != 1 || res.Plan.Changes[0].Kind != dnspod.ChangeModify ||
+		len(res.Skipped) != 1 || res.Skipped[0].Line != 2 {
+		t.Errorf("Replace: plan\n%sskipped %v", res.Plan, res.Skipped)
+	}
+	for _, r := range s.Records("example.com") {
+		if r.Name == "blog" && (r.Type != "CNAME" || r.Value != "blogs2.example.net.") {
+			t.Errorf("blog after Replace = %+v", r)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package dnspod
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+)
+
+//TypeError is returned by Record.Create/Modify when the record type is not allowed for the grade of the domain
+type TypeError struct {
+	Domain string
+	Grade  string
+	Type   RType
+}
+
+//Error interface
+func (e *TypeError) Error() string {
+	return fmt.Sprintf("dnspod: record type %s is not allowed for %s (grade %s)", e.Type, e.Domain, e.Grade)
+}
+
+//Type used to get the record types allowed for a domain grade,
+//the result is cached for the life of the client
+//grade:		The grade of the domain, such as Domain.Grade or RecordDomain.Grade
+func (p *RecordAPI) Type(grade string) ([]RType, error) {
+	return p.TypeContext(context.Background(), grade)
+}
+
+//TypeContext is like Type but carries ctx for cancellation and deadlines
+func (p *RecordAPI) TypeContext(ctx context.Context, grade string) (types []RType, err error) {
+	m := &p.c.meta
+	m.mu.Lock()
+	types, ok := m.types[grade]
+	m.mu.Unlock()
+	if ok {
+		return types, nil
+	}
+	var jsonRes struct {
+		Status Status  `json:"status"`
+		Types  []RType `json:"types"`
+	}
+	params := url.Values{}
+	params.Set("domain_grade", grade)
+	res, err := p.c.post(ctx, "Record.Type", params)
+	if err != nil {
+		return
+	}
+	if err = json.Unmarshal(res, &jsonRes); err != nil {
+		return
+	}
+	if jsonRes.Status.Code != 1 {
+		return nil, newAPIError("Record.Type", params, jsonRes.Status)
+	}
+	m.mu.Lock()
+	if m.types == nil {
+		m.types = map[string][]RType{}
+	}
+	m.types[grade] = jsonRes.Types
+	m.mu.Unlock()
+	return jsonRes.Types, nil
+}
+
+//checkType rejects the record types which are not allowed for the grade of the domain, before Create/Modify.
+//When the lookup fails the type is not checked, dnspod decides.
+func (p *RecordAPI) checkType(ctx context.Context, domain string, recordType RType) error {
+	if p.c.noPreflight || p.c.meta.unchecked(domain) {
+		return nil
+	}
+	grade, err := p.c.meta.grade(ctx, p.c, domain)
+	if err != nil {
+		p.c.meta.failed(domain, err)
+		return nil
+	}
+	types, err := p.TypeContext(ctx, grade)
+	if err != nil {
+		p.c.meta.failed(domain, err)
+		return nil
+	}
+	for _, t := range types {
+		if t == recordType {
+			return nil
+		}
+	}
+	return &TypeError{Domain: domain, Grade: grade, Type: recordType}
+}
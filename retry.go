@@ -0,0 +1,95 @@
+package dnspod
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+//RetryPolicy controls how the failed API calls are retried.
+//Network errors, HTTP 429/5xx and the dnspod throttling status (-2) are retried,
+//other failures are returned at once.
+type RetryPolicy struct {
+	MaxAttempts int           //Total attempts including the first one, 0 or 1 disables retry
+	BaseDelay   time.Duration //Delay before the first retry, doubled after each retry
+	MaxDelay    time.Duration //Upper bound of a single delay, 0 means no bound
+	RetryCreate bool          //Retry the non-idempotent calls (Record.Create/Domain.Create...) too, may create duplicates
+}
+
+//DefaultRetryPolicy is the RetryPolicy used by NewDnspod
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 3,
+	BaseDelay:   500 * time.Millisecond,
+	MaxDelay:    10 * time.Second,
+}
+
+//WithRetry sets the RetryPolicy, use RetryPolicy{} to disable retry
+func WithRetry(policy RetryPolicy) Option {
+	return func(c *client) {
+		c.retry = policy
+	}
+}
+
+//nonIdempotent lists the calls that must not be sent twice, besides *.Create
+var nonIdempotent = map[string]bool{
+	"Telephoneverify.Code": true, //sends a SMS each time
+}
+
+func idempotent(action string) bool {
+	return !strings.HasSuffix(action, ".Create") && !nonIdempotent[action]
+}
+
+//retryable reports whether the result of an attempt is a transient failure
+func retryable(ctx context.Context, res []byte, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	if err != nil {
+		var he *HTTPError
+		if errors.As(err, &he) {
+			return he.StatusCode == http.StatusTooManyRequests || he.StatusCode >= 500
+		}
+		var ne net.Error
+		return errors.As(err, &ne) || errors.Is(err, io.ErrUnexpectedEOF)
+	}
+	var jsonRes struct {
+		Status Status `json:"status"`
+	}
+	if json.Unmarshal(res, &jsonRes) != nil {
+		return false
+	}
+	return jsonRes.Status.Code == -2
+}
+
+//delay returns the backoff before the n-th retry, with jitter in [d/2, d]
+func (p RetryPolicy) delay(n int) time.Duration {
+	d := p.BaseDelay
+	for i := 1; i < n && (p.MaxDelay <= 0 || d < p.MaxDelay); i++ {
+		d *= 2
+	}
+	if p.MaxDelay > 0 && d > p.MaxDelay {
+		d = p.MaxDelay
+	}
+	if d <= 0 {
+		return 0
+	}
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
+
+//wait sleeps before the n-th retry, returns early when ctx is done
+func (p RetryPolicy) wait(ctx context.Context, n int) error {
+	t := time.NewTimer(p.delay(n))
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
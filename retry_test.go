@@ -0,0 +1,109 @@
+package dnspod
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+//flakyServer answers each call with the next of answers, then with a success.
+//An answer is an HTTP status, or a dnspod status code when it is negative.
+func flakyServer(t *testing.T, answers ...int) (*httptest.Server, *int32) {
+	var calls int32
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := int(atomic.AddInt32(&calls, 1))
+		code := 0
+		if n <= len(answers) {
+			code = answers[n-1]
+		}
+		switch {
+		case code > 0:
+			w.WriteHeader(code)
+		case code < 0:
+			w.Write([]byte(`{"status":{"code":"` + strconv.Itoa(code) + `","message":"busy"}}`))
+		default:
+			w.Write([]byte(`{"status":{"code":"1","message":"ok"},"record":{"id":"1"}}`))
+		}
+	}))
+	t.Cleanup(s.Close)
+	return s, &calls
+}
+
+func TestRetry(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond}
+	tests := []struct {
+		name    string
+		answers []int
+		call    func(d *Dnspod) error
+		calls   int32
+		ok      bool
+	}{
+		{"5xx then success", []int{502, 503}, func(d *Dnspod) error { return d.Record.Remove("example.com", 1) }, 3, true},
+		{"throttled then success", []int{-2}, func(d *Dnspod) error { return d.Record.Remove("example.com", 1) }, 2, true},
+		{"attempts exhausted", []int{500, 500, 500, 500}, func(d *Dnspod) error { return d.Record.Remove("example.com", 1) }, 3, false},
+		{"client error not retried", []int{404}, func(d *Dnspod) error { return d.Record.Remove("example.com", 1) }, 1, false},
+		{"create not retried", []int{502}, func(d *Dnspod) error {
+			_, err := d.Record.Create("example.com", RTypeA, "192.0.2.1")
+			return err
+		}, 1, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, calls := flakyServer(t, tt.answers...)
+			d := NewDnspod("1,token", WithBaseURL(s.URL), WithRetry(policy), WithPreflight(false))
+			err := tt.call(d)
+			if (err == nil) != tt.ok {
+				t.Errorf("err = %v, want ok %v", err, tt.ok)
+			}
+			if *calls != tt.calls {
+				t.Errorf("%d calls, want %d", *calls, tt.calls)
+			}
+		})
+	}
+
+	//the last HTTP error is returned
+	s, _ := flakyServer(t, 500, 500, 500)
+	d := NewDnspod("1,token", WithBaseURL(s.URL), WithRetry(policy))
+	var he *HTTPError
+	if err := d.Record.Remove("example.com", 1); !errors.As(err, &he) || he.StatusCode != 500 || he.Endpoint != "Record.Remove" {
+		t.Errorf("err = %v, want the *HTTPError of Record.Remove", err)
+	}
+}
+
+func TestRetryContext(t *testing.T) {
+	s, calls := flakyServer(t, 502, 502, 502)
+	d := NewDnspod("1,token", WithBaseURL(s.URL), WithRetry(RetryPolicy{MaxAttempts: 3, BaseDelay: time.Hour}))
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := d.Record.RemoveContext(ctx, "example.com", 1); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("err = %v, want context.DeadlineExceeded", err)
+	}
+	if *calls != 1 {
+		t.Errorf("%d calls, want 1", *calls)
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	p := RetryPolicy{BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}
+	for n, want := range map[int]time.Duration{
+		1:  100 * time.Millisecond,
+		2:  200 * time.Millisecond,
+		4:  800 * time.Millisecond,
+		5:  time.Second,
+		50: time.Second,
+	} {
+		for i := 0; i < 20; i++ {
+			if d := p.delay(n); d < want/2 || d > want {
+				t.Fatalf("delay(%d) = %v, want within [%v, %v]", n, d, want/2, want)
+			}
+		}
+	}
+	if d := (RetryPolicy{}).delay(3); d != 0 {
+		t.Errorf("delay without BaseDelay = %v, want 0", d)
+	}
+}
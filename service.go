@@ -0,0 +1,66 @@
+package dnspod
+
+import "context"
+
+//RecordService is the record operations of a backend, RecordAPI implements it
+type RecordService interface {
+	ListContext(ctx context.Context, domain string, opt ...RecordListOpt) (RecordList, error)
+	InfoContext(ctx context.Context, domain string, recordID int64) (Record, error)
+	CreateContext(ctx context.Context, domain string, recordType RType, value string, opt ...RecordOpt) (int64, error)
+	ModifyContext(ctx context.Context, domain string, recordID int64, recordType RType, value string, opt ...RecordOpt) error
+	RemoveContext(ctx context.Context, domain string, recordID int64) error
+	RemarkContext(ctx context.Context, domain string, recordID int64, remark string) error
+	StatusContext(ctx context.Context, domain string, recordID int64, enable Enable) error
+	DDNSContext(ctx context.Context, domain string, recordID int64, opt ...DDNSOpt) error
+}
+
+//DomainService is the domain operations of a backend, DomainAPI implements it
+type DomainService interface {
+	CreateContext(ctx context.Context, domain string, opt ...DomainCreateOpt) (int64, error)
+	ListContext(ctx context.Context, opt ...DomainListOpt) ([]Domain, error)
+	RemoveContext(ctx context.Context, domain string) error
+	StatusContext(ctx context.Context, domain string, enable Enable) error
+	InfoContext(ctx context.Context, domain string) (Domain, error)
+	LogContext(ctx context.Context, domain string, opt ...DomainLogOpt) ([]string, error)
+}
+
+//UserService is the account operations of a backend, UserAPI implements it
+type UserService interface {
+	DetailContext(ctx context.Context) (User, error)
+	ModifyDetailContext(ctx context.Context, opt ModifyDetailOpt) error
+	ModifyPasswordContext(ctx context.Context, oldPwd, newPwd string) error
+	ModifyEmailContext(ctx context.Context, pwd, oldEmail, newEmail string) error
+	PhoneVerifyContext(ctx context.Context, phone string) (VerifyInfo, error)
+	LogContext(ctx context.Context) ([]string, error)
+}
+
+//Provider is a whole dnspod backend, Dnspod implements it with the legacy token API
+//(NewDnspod) or the Tencent Cloud API 3.0 (NewTencentCloud).
+//Depend on Provider instead of *Dnspod to plug in a fake in tests.
+type Provider interface {
+	Records() RecordService
+	Domains() DomainService
+	Users() UserService
+}
+
+var (
+	_ RecordService = (*RecordAPI)(nil)
+	_ DomainService = (*DomainAPI)(nil)
+	_ UserService   = (*UserAPI)(nil)
+	_ Provider      = (*Dnspod)(nil)
+)
+
+//Records returns the record operations
+func (p *Dnspod) Records() RecordService {
+	return &p.Record
+}
+
+//Domains returns the domain operations
+func (p *Dnspod) Domains() DomainService {
+	return &p.Domain
+}
+
+//Users returns the account operations
+func (p *Dnspod) Users() UserService {
+	return &p.User
+}
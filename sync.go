@@ -0,0 +1,345 @@
+package dnspod
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+//DesiredRecord is a record of the desired zone, used by Record.Plan
+type DesiredRecord struct {
+	Name     string     `json:"name"`     //The host record, "@" by default
+	Type     RType      `json:"type"`     //Record type, uppercase
+	Value    string     `json:"value"`    //The value of the record(ip/mx/url...)
+	Line     RecordLine `json:"line"`     //"默认" by default
+	TTL      int        `json:"ttl"`      //600 by default
+	MX       int        `json:"mx"`       //MX priority, required when the type is MX
+	Weight   int        `json:"weight"`   //0 means not set
+	Disabled bool       `json:"disabled"` //Parsing does not take effect
+}
+
+func (r DesiredRecord) withDefaults() DesiredRecord {
+	if r.Name == "" {
+		r.Name = "@"
+	}
+	if r.Line == "" {
+		r.Line = LineDefault
+	}
+	if r.TTL == 0 {
+		r.TTL = 600
+	}
+	return r
+}
+
+//String interface
+func (r DesiredRecord) String() string {
+	s := r.Name + " " + string(r.Type) + " "
+	if r.Type == RTypeMX {
+		s += fmt.Sprintf("%d ", r.MX)
+	}
+	s += r.Value + fmt.Sprintf(" (line=%s ttl=%d", r.Line, r.TTL)
+	if r.Weight != 0 {
+		s += fmt.Sprintf(" weight=%d", r.Weight)
+	}
+	if r.Disabled {
+		s += " disabled"
+	}
+	return s + ")"
+}
+
+//toDesired converts a live record to a DesiredRecord, for comparison and display
+func toDesired(r Record) DesiredRecord {
+	return DesiredRecord{
+		Name:     r.Name,
+		Type:     RType(r.Type),
+		Value:    r.Value,
+		Line:     RecordLine(r.Line),
+		TTL:      r.TTL,
+		MX:       r.MX,
+		Weight:   r.Weight,
+		Disabled: !bool(r.Enabled),
+	}
+}
+
+//ChangeKind is the kind of a Change
+type ChangeKind string
+
+const (
+	//ChangeCreate creates Change.Desired
+	ChangeCreate ChangeKind = "create"
+	//ChangeModify modifies Change.Current into Change.Desired
+	ChangeModify ChangeKind = "modify"
+	//ChangeDelete removes Change.Current
+	ChangeDelete ChangeKind = "delete"
+)
+
+//Change is a step of a Plan
+type Change struct {
+	Kind    ChangeKind
+	Desired DesiredRecord //Set for create and modify
+	Current Record        //Set for modify and delete
+}
+
+//String interface
+func (c Change) String() string {
+	switch c.Kind {
+	case ChangeCreate:
+		return "+ " + c.Desired.String()
+	case ChangeModify:
+		return "~ " + toDesired(c.Current).String() + " => " + c.Desired.String()
+	}
+	return "- " + toDesired(c.Current).String()
+}
+
+//Plan is the changes turning the live records of a domain into the desired ones, in apply order
+type Plan struct {
+	Domain    string
+	Changes   []Change
+	Protected []Record //The live records left untouched by SyncOpt.Protect
+}
+
+//Empty reports whether the live records are already the desired ones
+func (p *Plan) Empty() bool {
+	return len(p.Changes) == 0
+}
+
+//String is the dry-run output of the plan
+func (p *Plan) String() string {
+	n := map[ChangeKind]int{}
+	for _, c := range p.Changes {
+		n[c.Kind]++
+	}
+	b := &strings.Builder{}
+	fmt.Fprintf(b, "Plan for %s: %d to create, %d to modify, %d to delete\n",
+		p.Domain, n[ChangeCreate], n[ChangeModify], n[ChangeDelete])
+	for _, c := range p.Changes {
+		fmt.Fprintf(b, "  %s\n", c)
+	}
+	for _, r := range p.Protected {
+		fmt.Fprintf(b, "  = %s (protected)\n", toDesired(r))
+	}
+	return b.String()
+}
+
+//SyncOpt is Optional arg of Record.Plan
+type SyncOpt struct {
+	//Protect reports the live records that are never modified or deleted,
+	//ProtectApexNS (the NS records at @ managed by dnspod) by default
+	Protect func(r Record) bool
+	//NoDelete keeps the live records which are not desired
+	NoDelete bool
+}
+
+//ProtectApexNS protects the NS records at @, they are managed by dnspod
+func ProtectApexNS(r Record) bool {
+	return r.Name == "@" && r.Type == string(RTypeNS)
+}
+
+//Plan used to compute the changes turning the live records of a domain into desired
+//domain: 		Domain name
+//desired:		All the records the domain should have
+//opt:			The other optional arg
+func (p *RecordAPI) Plan(domain string, desired []DesiredRecord, opt ...SyncOpt) (*Plan, error) {
+	return p.PlanContext(context.Background(), domain, desired, opt...)
+}
+
+//PlanContext is like Plan but carries ctx for cancellation and deadlines
+func (p *RecordAPI) PlanContext(ctx context.Context, domain string, desired []DesiredRecord, opt ...SyncOpt) (*Plan, error) {
+	return PlanZone(ctx, p, domain, desired, opt...)
+}
+
+//Apply used to apply a plan, the changes are applied in order and it stops at the first error
+func (p *RecordAPI) Apply(plan *Plan) error {
+	return p.ApplyContext(context.Background(), plan)
+}
+
+//ApplyContext is like Apply but carries ctx for cancellation and deadlines
+func (p *RecordAPI) ApplyContext(ctx context.Context, plan *Plan) error {
+	return ApplyPlan(ctx, p, plan)
+}
+
+//syncKey groups the records which can be paired
+type syncKey struct {
+	name  string
+	rtype string
+	line  string
+}
+
+//PlanZone is Record.Plan on the records served by s
+func PlanZone(ctx context.Context, s RecordService, domain string, want []DesiredRecord, opt ...SyncOpt) (*Plan, error) {
+	plan, _, err := planZone(ctx, s, domain, want, opt...)
+	return plan, err
+}
+
+//planZone is PlanZone, it also returns the live records
+func planZone(ctx context.Context, s RecordService, domain string, want []DesiredRecord, opt ...SyncOpt) (*Plan, []Record, error) {
+	var o SyncOpt
+	if len(opt) > 0 {
+		o = opt[0]
+	}
+	if o.Protect == nil {
+		o.Protect = ProtectApexNS
+	}
+	plan := &Plan{Domain: domain}
+	live := map[syncKey][]Record{}
+	var all, protected []Record
+	it := IterateRecords(ctx, s, domain)
+	for it.Next() {
+		r := it.Record()
+		all = append(all, r)
+		if o.Protect(r) {
+			protected = append(protected, r)
+			continue
+		}
+		k := syncKey{strings.ToLower(r.Name), r.Type, r.Line}
+		live[k] = append(live[k], r)
+	}
+	if err := it.Err(); err != nil {
+		return nil, nil, err
+	}
+	plan.Protected = protected
+
+	wanted := map[syncKey][]DesiredRecord{}
+	var keys []syncKey
+	for _, d := range want {
+		d = d.withDefaults()
+		if d.Type == RTypeMX && d.MX == 0 {
+			return nil, nil, fmt.Errorf("dnspod: %s: MX priority is required", d)
+		}
+		//the desired records which are protected already exist
+		if containsValue(protected, d) {
+			continue
+		}
+		k := syncKey{strings.ToLower(d.Name), string(d.Type), string(d.Line)}
+		if _, ok := wanted[k]; !ok {
+			keys = append(keys, k)
+		}
+		wanted[k] = append(wanted[k], d)
+	}
+	for k := range live {
+		if _, ok := wanted[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := keys[i], keys[j]
+		if a.name != b.name {
+			return a.name < b.name
+		}
+		if a.rtype != b.rtype {
+			return a.rtype < b.rtype
+		}
+		return a.line < b.line
+	})
+
+	var creates, modifies, deletes []Change
+	for _, k := range keys {
+		cur, ds := live[k], wanted[k]
+		//pair the records having the same value
+		var rest []DesiredRecord
+		for _, d := range ds {
+			i := indexValue(cur, d)
+			if i < 0 {
+				rest = append(rest, d)
+				continue
+			}
+			if !sameRecord(cur[i], d) {
+				modifies = append(modifies, Change{Kind: ChangeModify, Desired: d, Current: cur[i]})
+			}
+			cur = append(cur[:i:i], cur[i+1:]...)
+		}
+		//reuse the other live records, then create or delete the rest
+		for i, d := range rest {
+			if i < len(cur) {
+				modifies = append(modifies, Change{Kind: ChangeModify, Desired: d, Current: cur[i]})
+			} else {
+				creates = append(creates, Change{Kind: ChangeCreate, Desired: d})
+			}
+		}
+		if len(rest) < len(cur) && !o.NoDelete {
+			for _, r := range cur[len(rest):] {
+				deletes = append(deletes, Change{Kind: ChangeDelete, Current: r})
+			}
+		}
+	}
+
+	//a CNAME can not coexist with other records of the same name and line:
+	//the conflicting deletes go first, the others go last to keep the zone resolving
+	var early, late []Change
+	for _, d := range deletes {
+		if conflicts(d.Current, creates, modifies) {
+			early = append(early, d)
+		} else {
+			late = append(late, d)
+		}
+	}
+	plan.Changes = append(plan.Changes, early...)
+	plan.Changes = append(plan.Changes, modifies...)
+	plan.Changes = append(plan.Changes, creates...)
+	plan.Changes = append(plan.Changes, late...)
+	return plan, all, nil
+}
+
+func containsValue(list []Record, d DesiredRecord) bool {
+	return indexValue(list, d) >= 0
+}
+
+func indexValue(list []Record, d DesiredRecord) int {
+	for i, r := range list {
+		if strings.EqualFold(r.Name, d.Name) && r.Type == string(d.Type) && r.Line == string(d.Line) && SameValue(d.Type, r.Value, d.Value) {
+			return i
+		}
+	}
+	return -1
+}
+
+//sameRecord reports whether r already has the attributes of d
+func sameRecord(r Record, d DesiredRecord) bool {
+	return r.TTL == d.TTL &&
+		bool(r.Enabled) != d.Disabled &&
+		(d.Type != RTypeMX || r.MX == d.MX) &&
+		(d.Weight == 0 || r.Weight == d.Weight)
+}
+
+//conflicts reports whether the record would break the CNAME rule with a created or modified record
+func conflicts(r Record, changes ...[]Change) bool {
+	for _, list := range changes {
+		for _, c := range list {
+			d := c.Desired
+			if strings.EqualFold(d.Name, r.Name) && string(d.Line) == r.Line &&
+				(d.Type == RTypeCNAME || r.Type == string(RTypeCNAME)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+//ApplyPlan is Record.Apply on the records served by s
+func ApplyPlan(ctx context.Context, s RecordService, plan *Plan) error {
+	for _, c := range plan.Changes {
+		var err error
+		d := c.Desired
+		o := RecordOpt{
+			SubDomain:  d.Name,
+			RecordLine: d.Line,
+			Disable:    d.Disabled,
+			MX:         d.MX,
+			TTL:        d.TTL,
+			Weight:     d.Weight,
+		}
+		switch c.Kind {
+		case ChangeCreate:
+			_, err = s.CreateContext(ctx, plan.Domain, d.Type, d.Value, o)
+		case ChangeModify:
+			err = s.ModifyContext(ctx, plan.Domain, c.Current.ID, d.Type, d.Value, o)
+		case ChangeDelete:
+			err = s.RemoveContext(ctx, plan.Domain, c.Current.ID)
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", c, err)
+		}
+	}
+	return nil
+}
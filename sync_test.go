@@ -0,0 +1,136 @@
+package dnspod_test
+
+import (
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/bigemon/dnspod"
+	"github.com/bigemon/dnspod/dnspodtest"
+)
+
+//zoneOf lists the records of a domain as sorted "name type value" lines
+func zoneOf(s *dnspodtest.Server, domain string) string {
+	var lines []string
+	for _, r := range s.Records(domain) {
+		lines = append(lines, r.Name+" "+r.Type+" "+r.Value)
+	}
+	sort.Strings(lines)
+	return strings.Join(lines, "\n")
+}
+
+//kinds lists the kinds of the changes of a plan, in order
+func kinds(p *dnspod.Plan) string {
+	var k []string
+	for _, c := range p.Changes {
+		k = append(k, string(c.Kind))
+	}
+	return strings.Join(k, " ")
+}
+
+func TestPlanApply(t *testing.T) {
+	tests := []struct {
+		name    string
+		live    []dnspod.Record
+		desired []dnspod.DesiredRecord
+		opt     dnspod.SyncOpt
+		kinds   string
+		zone    string
+	}{
+		{
+			name:    "A to CNAME deletes first",
+			live:    []dnspod.Record{{Name: "www", Type: "A", Value: "192.0.2.1"}, {Name: "www", Type: "AAAA", Value: "2001:db8::1"}},
+			desired: []dnspod.DesiredRecord{{Name: "www", Type: dnspod.RTypeCNAME, Value: "cdn.example.net."}},
+			kinds:   "delete delete create",
+			zone:    "@ NS f1g1ns1.dnspod.net.\n@ NS f1g1ns2.dnspod.net.\nwww CNAME cdn.example.net.",
+		},
+		{
+			name:    "CNAME to A",
+			live:    []dnspod.Record{{Name: "www", Type: "CNAME", Value: "cdn.example.net."}},
+			desired: []dnspod.DesiredRecord{{Name: "www", Type: dnspod.RTypeA, Value: "192.0.2.1"}},
+			kinds:   "delete create",
+			zone:    "@ NS f1g1ns1.dnspod.net.\n@ NS f1g1ns2.dnspod.net.\nwww A 192.0.2.1",
+		},
+		{
+			name:    "apex NS protected",
+			live:    []dnspod.Record{{Name: "@", Type: "A", Value: "192.0.2.1"}},
+			desired: []dnspod.DesiredRecord{{Type: dnspod.RTypeA, Value: "192.0.2.1"}, {Type: dnspod.RTypeNS, Value: "f1g1ns1.dnspod.net."}},
+			kinds:   "",
+			zone:    "@ A 192.0.2.1\n@ NS f1g1ns1.dnspod.net.\n@ NS f1g1ns2.dnspod.net.",
+		},
+		{
+			name:    "NoDelete",
+			live:    []dnspod.Record{{Name: "old", Type: "A", Value: "192.0.2.1"}, {Name: "www", Type: "A", Value: "192.0.2.1"}, {Name: "www", Type: "A", Value: "192.0.2.2"}},
+			desired: []dnspod.DesiredRecord{{Name: "www", Type: dnspod.RTypeA, Value: "192.0.2.1"}, {Name: "new", Type: dnspod.RTypeTXT, Value: "hello"}},
+			opt:     dnspod.SyncOpt{NoDelete: true},
+			kinds:   "create",
+			zone:    "@ NS f1g1ns1.dnspod.net.\n@ NS f1g1ns2.dnspod.net.\nnew TXT hello\nold A 192.0.2.1\nwww A 192.0.2.1\nwww A 192.0.2.2",
+		},
+		{
+			name: "round robin reuses the live records",
+			live: []dnspod.Record{{Name: "www", Type: "A", Value: "192.0.2.1"}, {Name: "www", Type: "A", Value: "192.0.2.2"}, {Name: "www", Type: "A", Value: "192.0.2.3"}},
+			desired: []dnspod.DesiredRecord{
+				{Name: "www", Type: dnspod.RTypeA, Value: "192.0.2.3"},
+				{Name: "www", Type: dnspod.RTypeA, Value: "192.0.2.4"},
+			},
+			kinds: "modify delete",
+			zone:  "@ NS f1g1ns1.dnspod.net.\n@ NS f1g1ns2.dnspod.net.\nwww A 192.0.2.3\nwww A 192.0.2.4",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newServer(t)
+			s.AddDomain("example.com")
+			for _, r := range tt.live {
+				r.Enabled = true
+				s.AddRecord("example.com", r)
+			}
+			d := s.Dnspod()
+			plan, err := d.Record.Plan("example.com", tt.desired, tt.opt)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := kinds(plan); got != tt.kinds {
+				t.Fatalf("changes = %q, want %q\n%s", got, tt.kinds, plan)
+			}
+			if len(plan.Protected) != len(dnspodtest.DefaultNS) {
+				t.Errorf("%d protected records, want the %d apex NS", len(plan.Protected), len(dnspodtest.DefaultNS))
+			}
+			if err := d.Record.Apply(plan); err != nil {
+				t.Fatalf("Apply: %v\n%s", err, plan)
+			}
+			if got := zoneOf(s, "example.com"); got != tt.zone {
+				t.Errorf("zone after Apply =\n%s\nwant\n%s", got, tt.zone)
+			}
+			//the zone is now the desired one
+			if plan, err = d.Record.Plan("example.com", tt.desired, tt.opt); err != nil || !plan.Empty() {
+				t.Errorf("second Plan = %v, %v, want an empty plan", plan, err)
+			}
+		})
+	}
+}
+
+func TestPlanReuseKeepsID(t *testing.T) {
+	s := newServer(t)
+	s.AddDomain("example.com")
+	id := s.AddRecord("example.com", dnspod.Record{Name: "www", Type: "A", Value: "192.0.2.1", Enabled: true})
+	d := s.Dnspod()
+	plan, err := d.Record.Plan("example.com", []dnspod.DesiredRecord{{Name: "www", Type: dnspod.RTypeA, Value: "192.0.2.2", TTL: 3600}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plan.Changes) != 1 || plan.Changes[0].Kind != dnspod.ChangeModify || plan.Changes[0].Current.ID != id {
+		t.Fatalf("plan =\n%s", plan)
+	}
+	if err := d.Record.Apply(plan); err != nil {
+		t.Fatal(err)
+	}
+	if r, err := d.Record.Info("example.com", id); err != nil || r.Value != "192.0.2.2" || r.TTL != 3600 {
+		t.Errorf("Info = %+v, %v", r, err)
+	}
+
+	//an MX without priority is rejected before any call
+	if _, err := d.Record.Plan("example.com", []dnspod.DesiredRecord{{Type: dnspod.RTypeMX, Value: "mx.example.com."}}); err == nil {
+		t.Error("Plan of an MX without priority succeeded")
+	}
+}
@@ -0,0 +1,532 @@
+package dnspod
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//----------------------------------------------------------------------------------
+//# Tencent Cloud API 3.0 backend
+//
+//The Record/Domain/User APIs build the legacy dnsapi.cn params, the innermost
+//Handler translates them into a signed Tencent Cloud API 3.0 call and translates
+//the answer back into the legacy format. So retry, rate limit and middleware
+//work the same way for both backends.
+
+const (
+	//TencentCloudEndpoint is the Tencent Cloud API 3.0 endpoint of DNSPod
+	TencentCloudEndpoint = "https://dnspod.tencentcloudapi.com"
+
+	tc3Service = "dnspod"
+	tc3Version = "2021-03-23"
+)
+
+//ErrUnsupported the operation is not supported by the backend
+var ErrUnsupported = errors.New("dnspod: operation not supported by the backend")
+
+//NewTencentCloud creates a Dnspod instance calling the Tencent Cloud API 3.0,
+//signed with the SecretId/SecretKey of a Tencent Cloud account (TC3-HMAC-SHA256).
+//Record/Domain/User.Detail work as with NewDnspod, the other User APIs return ErrUnsupported,
+//so does Record.DDNS without opt.Value.
+//opt:			The optional settings, WithBaseURL overrides TencentCloudEndpoint
+func NewTencentCloud(secretID, secretKey string, opt ...Option) *Dnspod {
+	return newDnspod(&client{
+		baseURL: TencentCloudEndpoint,
+		tc3:     &tc3Credential{secretID: secretID, secretKey: secretKey},
+	}, opt)
+}
+
+//tc3Credential is the SecretId/SecretKey pair of a Tencent Cloud account
+type tc3Credential struct {
+	secretID  string
+	secretKey string
+}
+
+//tc3Actions maps the legacy APIs to the Tencent Cloud actions
+var tc3Actions = map[string]string{
+	"Record.List":   "DescribeRecordList",
+	"Record.Create": "CreateRecord",
+	"Record.Modify": "ModifyRecord",
+	"Record.Remove": "DeleteRecord",
+	"Record.Remark": "ModifyRecordRemark",
+	"Record.Info":   "DescribeRecord",
+	"Record.Status": "ModifyRecordStatus",
+	"Record.Ddns":   "ModifyDynamicDNS",
+	"Record.Line":   "DescribeRecordLineList",
+	"Record.Type":   "DescribeRecordType",
+	"Domain.Create": "CreateDomain",
+	"Domain.List":   "DescribeDomainList",
+	"Domain.Remove": "DeleteDomain",
+	"Domain.Status": "ModifyDomainStatus",
+	"Domain.Info":   "DescribeDomain",
+	"Domain.Log":    "DescribeDomainLogList",
+	"User.Detail":   "DescribeUserDetail",
+}
+
+//tc3Param is the Tencent Cloud name of a legacy param
+type tc3Param struct {
+	name    string
+	numeric bool //sent as a JSON number
+	upper   bool //value converted to upper case, such as "enable" => "ENABLE"
+}
+
+var tc3Params = map[string]tc3Param{
+	"domain":         {name: "Domain"},
+	"domain_id":      {name: "DomainId", numeric: true},
+	"record_id":      {name: "RecordId", numeric: true},
+	"sub_domain":     {name: "SubDomain"},
+	"record_type":    {name: "RecordType"},
+	"record_line":    {name: "RecordLine"},
+	"record_line_id": {name: "RecordLineId"},
+	"value":          {name: "Value"},
+	"mx":             {name: "MX", numeric: true},
+	"ttl":            {name: "TTL", numeric: true},
+	"weight":         {name: "Weight", numeric: true},
+	"status":         {name: "Status", upper: true},
+	"remark":         {name: "Remark"},
+	"keyword":        {name: "Keyword"},
+	"offset":         {name: "Offset", numeric: true},
+	"length":         {name: "Limit", numeric: true},
+	"group_id":       {name: "GroupId", numeric: true},
+	"is_mark":        {name: "IsMark"},
+	"type":           {name: "Type", upper: true},
+	"domain_grade":   {name: "DomainGrade"},
+}
+
+//tc3Request translates the legacy params into the body of a Tencent Cloud action
+func tc3Request(endpoint string, params url.Values) (action string, body []byte, err error) {
+	action, ok := tc3Actions[endpoint]
+	if !ok {
+		return "", nil, ErrUnsupported
+	}
+	if endpoint == "Record.Ddns" && params.Get("value") == "" {
+		//dnsapi.cn uses the IP of the caller, ModifyDynamicDNS has no such default
+		return "", nil, fmt.Errorf("%w: Record.Ddns needs opt.Value", ErrUnsupported)
+	}
+	m := map[string]interface{}{}
+	for k := range params {
+		p, ok := tc3Params[k]
+		if !ok {
+			continue
+		}
+		v := params.Get(k)
+		switch {
+		case p.numeric:
+			n, err := strconv.ParseInt(v, 10, 64)
+			if err != nil {
+				return "", nil, fmt.Errorf("invalid %s %q", k, v)
+			}
+			m[p.name] = n
+		case p.upper:
+			m[p.name] = strings.ToUpper(v)
+		default:
+			m[p.name] = v
+		}
+	}
+	switch endpoint {
+	case "Record.List":
+		//DescribeRecordList names it Subdomain
+		if v, ok := m["SubDomain"]; ok {
+			m["Subdomain"] = v
+			delete(m, "SubDomain")
+		}
+	case "Domain.Status":
+		m["Status"] = params.Get("status")
+	}
+	body, err = json.Marshal(m)
+	return action, body, err
+}
+
+//sendTC3 is the innermost Handler of the Tencent Cloud backend
+func (c *client) sendTC3(ctx context.Context, req *Request) (*Response, error) {
+	action, payload, err := tc3Request(req.Endpoint, req.Params)
+	if err != nil {
+		return nil, err
+	}
+	u, err := url.Parse(c.baseURL)
+	if err != nil {
+		return nil, err
+	}
+	hreq, err := http.NewRequestWithContext(ctx, "POST", c.baseURL, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	c.tc3.sign(hreq, u.Host, action, payload, time.Now())
+	res, code, err := c.doHTTP(hreq)
+	if err != nil {
+		return &Response{StatusCode: code}, err
+	}
+	res, err = tc3Response(req.Endpoint, req.Params, res)
+	return &Response{StatusCode: code, Body: res}, err
+}
+
+//sign adds the TC3-HMAC-SHA256 Authorization and the X-TC-* headers
+func (p *tc3Credential) sign(req *http.Request, host, action string, payload []byte, now time.Time) {
+	req.Host = host
+	req.Header.Set("Content-Type", tc3ContentType)
+	req.Header.Set("Authorization", p.authorization(tc3Service, host, action, payload, now))
+	req.Header.Set("X-TC-Action", action)
+	req.Header.Set("X-TC-Timestamp", strconv.FormatInt(now.Unix(), 10))
+	req.Header.Set("X-TC-Version", tc3Version)
+}
+
+const tc3ContentType = "application/json; charset=utf-8"
+
+//tc3CanonicalRequest is the request signed, with the content-type, host and x-tc-action headers
+func tc3CanonicalRequest(host, action string, payload []byte) string {
+	return "POST\n/\n\n" +
+		"content-type:" + tc3ContentType + "\nhost:" + host + "\nx-tc-action:" + strings.ToLower(action) + "\n\n" +
+		"content-type;host;x-tc-action\n" + sha256Hex(payload)
+}
+
+//authorization returns the Authorization header of a call to service
+func (p *tc3Credential) authorization(service, host, action string, payload []byte, now time.Time) string {
+	date := now.UTC().Format("2006-01-02")
+	scope := date + "/" + service + "/tc3_request"
+	stringToSign := "TC3-HMAC-SHA256\n" + strconv.FormatInt(now.Unix(), 10) + "\n" + scope + "\n" +
+		sha256Hex([]byte(tc3CanonicalRequest(host, action, payload)))
+	key := hmacSHA256([]byte("TC3"+p.secretKey), date)
+	key = hmacSHA256(key, service)
+	key = hmacSHA256(key, "tc3_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+	return "TC3-HMAC-SHA256 Credential=" + p.secretID + "/" + scope + ", SignedHeaders=content-type;host;x-tc-action, Signature=" + signature
+}
+
+func sha256Hex(b []byte) string {
+	h := sha256.Sum256(b)
+	return hex.EncodeToString(h[:])
+}
+
+func hmacSHA256(key []byte, msg string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(msg))
+	return h.Sum(nil)
+}
+
+//tc3Response translates the answer of a Tencent Cloud action into the legacy format
+func tc3Response(endpoint string, params url.Values, res []byte) ([]byte, error) {
+	var jsonRes struct {
+		Response json.RawMessage `json:"Response"`
+	}
+	if err := json.Unmarshal(res, &jsonRes); err != nil {
+		return nil, &ResponseError{Reason: "invalid JSON body", Snippet: snippet(res)}
+	}
+	var head struct {
+		Error *struct {
+			Code    string `json:"Code"`
+			Message string `json:"Message"`
+		} `json:"Error"`
+	}
+	if err := json.Unmarshal(jsonRes.Response, &head); err != nil {
+		return nil, &ResponseError{Reason: "invalid JSON body", Snippet: snippet(res)}
+	}
+	out := map[string]interface{}{}
+	status := Status{Code: 1, Message: "Action completed successful", CreatedAt: Time(time.Now())}
+	if head.Error != nil {
+		status.Code = tc3Code(endpoint, head.Error.Code)
+		status.Message = head.Error.Code + ": " + head.Error.Message
+	} else if err := tc3Convert(endpoint, params, jsonRes.Response, out); err != nil {
+		return nil, &ResponseError{Reason: err.Error(), Snippet: snippet(res)}
+	}
+	out["status"] = status
+	return json.Marshal(out)
+}
+
+//tc3Code maps a Tencent Cloud error code to the legacy status code of endpoint
+func tc3Code(endpoint, code string) int {
+	module := endpoint[:strings.IndexByte(endpoint, '.')+1]
+	switch {
+	case strings.HasPrefix(code, "AuthFailure"):
+		return -1
+	case strings.HasPrefix(code, "RequestLimitExceeded"):
+		return -2
+	case strings.HasPrefix(code, "UnauthorizedOperation"):
+		return -7
+	case code == "ResourceNotFound.NoDataOfRecord" && endpoint == "Record.List":
+		return 10
+	case code == "ResourceNotFound.NoDataOfDomain" && endpoint == "Domain.List":
+		return 9
+	case strings.Contains(code, "RecordIdInvalid"), strings.Contains(code, "NoDataOfRecord"):
+		return 8
+	case strings.Contains(code, "DomainNotExist"), strings.Contains(code, "DomainIdInvalid"),
+		strings.Contains(code, "NoDataOfDomain"), strings.Contains(code, "DomainInvalid"):
+		return 6
+	case strings.Contains(code, "DomainExists"):
+		return 7
+	case strings.Contains(code, "Lock") && module == "Record.":
+		return 21
+	case strings.Contains(code, "Lock"):
+		return 7
+	}
+	return 3
+}
+
+//tc3Time parses the "2006-01-02 15:04:05" times of Tencent Cloud
+func tc3Time(s string) Time {
+	t, _ := time.ParseInLocation(timeFormart, s, time.Local)
+	return Time(t)
+}
+
+type tc3Record struct {
+	RecordID      int64  `json:"RecordId"`
+	Value         string `json:"Value"`
+	Status        string `json:"Status"`
+	UpdatedOn     string `json:"UpdatedOn"`
+	Name          string `json:"Name"`
+	Line          string `json:"Line"`
+	LineID        string `json:"LineId"`
+	Type          string `json:"Type"`
+	Weight        int    `json:"Weight"`
+	MonitorStatus string `json:"MonitorStatus"`
+	Remark        string `json:"Remark"`
+	TTL           int    `json:"TTL"`
+	MX            int    `json:"MX"`
+}
+
+func (r tc3Record) record() Record {
+	return Record{
+		ID:            r.RecordID,
+		TTL:           r.TTL,
+		Value:         r.Value,
+		Enabled:       Enabled(r.Status == "ENABLE"),
+		UpdatedOn:     tc3Time(r.UpdatedOn),
+		Name:          r.Name,
+		Line:          r.Line,
+		LineID:        r.LineID,
+		Type:          r.Type,
+		Weight:        r.Weight,
+		MonitorStatus: r.MonitorStatus,
+		Remark:        r.Remark,
+		MX:            r.MX,
+	}
+}
+
+type tc3Domain struct {
+	DomainID         int64    `json:"DomainId"`
+	Name             string   `json:"Name"`
+	Domain           string   `json:"Domain"`
+	Status           string   `json:"Status"`
+	TTL              int      `json:"TTL"`
+	CNAMESpeedup     string   `json:"CNAMESpeedup"`
+	CnameSpeedup     string   `json:"CnameSpeedup"`
+	Grade            string   `json:"Grade"`
+	GroupID          int      `json:"GroupId"`
+	SearchEnginePush string   `json:"SearchEnginePush"`
+	IsMark           string   `json:"IsMark"`
+	Remark           string   `json:"Remark"`
+	Punycode         string   `json:"Punycode"`
+	GradeTitle       string   `json:"GradeTitle"`
+	IsVip            string   `json:"IsVip"`
+	RecordCount      int      `json:"RecordCount"`
+	CreatedOn        string   `json:"CreatedOn"`
+	UpdatedOn        string   `json:"UpdatedOn"`
+	Owner            string   `json:"Owner"`
+	DnspodNsList     []string `json:"DnspodNsList"`
+}
+
+func (d tc3Domain) domain() Domain {
+	name := d.Name
+	if name == "" {
+		name = d.Domain
+	}
+	return Domain{
+		ID:               d.DomainID,
+		Status:           Enable(strings.EqualFold(d.Status, "ENABLE")),
+		Grade:            d.Grade,
+		GroupID:          d.GroupID,
+		SearchEnginePush: Yes(strings.EqualFold(d.SearchEnginePush, "YES")),
+		IsMark:           Yes(strings.EqualFold(d.IsMark, "YES")),
+		TTL:              d.TTL,
+		CnameSpeedup:     Enable(strings.EqualFold(d.CNAMESpeedup+d.CnameSpeedup, "ENABLE")),
+		Remark:           d.Remark,
+		CreatedOn:        tc3Time(d.CreatedOn),
+		UpdatedOn:        tc3Time(d.UpdatedOn),
+		Punycode:         d.Punycode,
+		Name:             name,
+		GradeTitle:       d.GradeTitle,
+		IsVIP:            Yes(strings.EqualFold(d.IsVip, "YES")),
+		Owner:            d.Owner,
+		Records:          d.RecordCount,
+	}
+}
+
+//tc3Convert fills out with the legacy fields of the endpoint answer
+func tc3Convert(endpoint string, params url.Values, res json.RawMessage, out map[string]interface{}) error {
+	switch endpoint {
+	case "Record.List":
+		var r struct {
+			RecordCountInfo struct {
+				SubdomainCount int `json:"SubdomainCount"`
+				ListCount      int `json:"ListCount"`
+				TotalCount     int `json:"TotalCount"`
+			} `json:"RecordCountInfo"`
+			RecordList []tc3Record `json:"RecordList"`
+		}
+		if err := json.Unmarshal(res, &r); err != nil {
+			return err
+		}
+		records := make([]Record, 0, len(r.RecordList))
+		for _, v := range r.RecordList {
+			records = append(records, v.record())
+		}
+		out["domain"] = RecordDomain{Name: params.Get("domain")}
+		out["info"] = RecordInfo{
+			SubDomains:  r.RecordCountInfo.SubdomainCount,
+			RecordTotal: r.RecordCountInfo.TotalCount,
+			RecordsNum:  r.RecordCountInfo.ListCount,
+		}
+		out["records"] = records
+	case "Record.Info":
+		var r struct {
+			RecordInfo struct {
+				ID            int64  `json:"Id"`
+				SubDomain     string `json:"SubDomain"`
+				RecordType    string `json:"RecordType"`
+				RecordLine    string `json:"RecordLine"`
+				RecordLineID  string `json:"RecordLineId"`
+				Value         string `json:"Value"`
+				Weight        int    `json:"Weight"`
+				MX            int    `json:"MX"`
+				TTL           int    `json:"TTL"`
+				Enabled       int    `json:"Enabled"`
+				MonitorStatus string `json:"MonitorStatus"`
+				Remark        string `json:"Remark"`
+				UpdatedOn     string `json:"UpdatedOn"`
+			} `json:"RecordInfo"`
+		}
+		if err := json.Unmarshal(res, &r); err != nil {
+			return err
+		}
+		i := r.RecordInfo
+		out["record"] = Record{
+			ID:            i.ID,
+			TTL:           i.TTL,
+			Value:         i.Value,
+			Enabled:       i.Enabled == 1,
+			UpdatedOn:     tc3Time(i.UpdatedOn),
+			Name:          i.SubDomain,
+			Line:          i.RecordLine,
+			LineID:        i.RecordLineID,
+			Type:          i.RecordType,
+			Weight:        i.Weight,
+			MonitorStatus: i.MonitorStatus,
+			Remark:        i.Remark,
+			MX:            i.MX,
+		}
+	case "Record.Create", "Record.Ddns":
+		var r struct {
+			RecordID int64 `json:"RecordId"`
+		}
+		if err := json.Unmarshal(res, &r); err != nil {
+			return err
+		}
+		out["record"] = map[string]string{"id": strconv.FormatInt(r.RecordID, 10)}
+	case "Record.Line":
+		var r struct {
+			LineList []struct {
+				Name   string `json:"Name"`
+				LineID string `json:"LineId"`
+			} `json:"LineList"`
+		}
+		if err := json.Unmarshal(res, &r); err != nil {
+			return err
+		}
+		lines := make([]string, 0, len(r.LineList))
+		ids := map[string]string{}
+		for _, l := range r.LineList {
+			lines = append(lines, l.Name)
+			ids[l.Name] = l.LineID
+		}
+		out["lines"] = lines
+		out["line_ids"] = ids
+	case "Record.Type":
+		var r struct {
+			TypeList []string `json:"TypeList"`
+		}
+		if err := json.Unmarshal(res, &r); err != nil {
+			return err
+		}
+		out["types"] = r.TypeList
+	case "Domain.Create":
+		var r struct {
+			DomainInfo struct {
+				ID int64 `json:"Id"`
+			} `json:"DomainInfo"`
+		}
+		if err := json.Unmarshal(res, &r); err != nil {
+			return err
+		}
+		out["domain"] = map[string]string{"id": strconv.FormatInt(r.DomainInfo.ID, 10)}
+	case "Domain.List":
+		var r struct {
+			DomainList []tc3Domain `json:"DomainList"`
+		}
+		if err := json.Unmarshal(res, &r); err != nil {
+			return err
+		}
+		domains := make([]Domain, 0, len(r.DomainList))
+		for _, v := range r.DomainList {
+			domains = append(domains, v.domain())
+		}
+		out["domains"] = domains
+	case "Domain.Info":
+		var r struct {
+			DomainInfo tc3Domain `json:"DomainInfo"`
+		}
+		if err := json.Unmarshal(res, &r); err != nil {
+			return err
+		}
+		out["domain"] = r.DomainInfo.domain()
+	case "Domain.Log":
+		var r struct {
+			LogList []string `json:"LogList"`
+		}
+		if err := json.Unmarshal(res, &r); err != nil {
+			return err
+		}
+		out["log"] = r.LogList
+	case "User.Detail":
+		var r struct {
+			UserInfo struct {
+				Nick              string `json:"Nick"`
+				ID                int64  `json:"Id"`
+				Email             string `json:"Email"`
+				Status            string `json:"Status"`
+				Telephone         string `json:"Telephone"`
+				EmailVerified     string `json:"EmailVerified"`
+				TelephoneVerified string `json:"TelephoneVerified"`
+				UserGrade         string `json:"UserGrade"`
+				RealName          string `json:"RealName"`
+				WechatBinded      string `json:"WechatBinded"`
+			} `json:"UserInfo"`
+		}
+		if err := json.Unmarshal(res, &r); err != nil {
+			return err
+		}
+		i := r.UserInfo
+		out["info"] = map[string]User{"user": {
+			RealName:          i.RealName,
+			Telephone:         i.Telephone,
+			Nick:              i.Nick,
+			ID:                i.ID,
+			Email:             i.Email,
+			Status:            i.Status,
+			EmailVerified:     i.EmailVerified,
+			TelephoneVerified: i.TelephoneVerified,
+			WeixinBinded:      i.WechatBinded,
+			UserGrade:         i.UserGrade,
+		}}
+	}
+	return nil
+}
@@ -0,0 +1,227 @@
+package dnspod
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+//The example of the Tencent Cloud API 3.0 signature documentation (签名方法 v3)
+var (
+	tc3ExampleCredential = tc3Credential{
+		secretID:  "AKIDz8krbsJ5yKBZQpn74WFkmLPx3EXAMPLE",
+		secretKey: "Gu5t9xGARNpq86cd98joQYCN3EXAMPLE",
+	}
+	tc3ExamplePayload = []byte(`{"Limit": 1, "Filters": [{"Values": ["\u672a\u547d\u540d"], "Name": "instance-name"}]}`)
+	tc3ExampleTime    = time.Unix(1551113065, 0)
+)
+
+func TestTC3CanonicalRequest(t *testing.T) {
+	if h := sha256Hex(tc3ExamplePayload); h != "35e9c5b0e3ae67532d3c9f17ead6c90222632e5b1ff7f6e89887f1398934f064" {
+		t.Fatalf("HashedRequestPayload = %s", h)
+	}
+	c := tc3CanonicalRequest("cvm.tencentcloudapi.com", "DescribeInstances", tc3ExamplePayload)
+	if h := sha256Hex([]byte(c)); h != "7019a55be8395899b900fb5564e4200d984910f34794a27cb3fb7d10ff6a1e84" {
+		t.Errorf("HashedCanonicalRequest = %s of\n%s", h, c)
+	}
+}
+
+func TestTC3Sign(t *testing.T) {
+	//the documentation masks the SecretKey, this is the signature with the EXAMPLE one
+	auth := tc3ExampleCredential.authorization("cvm", "cvm.tencentcloudapi.com", "DescribeInstances", tc3ExamplePayload, tc3ExampleTime)
+	want := "TC3-HMAC-SHA256 Credential=AKIDz8krbsJ5yKBZQpn74WFkmLPx3EXAMPLE/2019-02-25/cvm/tc3_request, " +
+		"SignedHeaders=content-type;host;x-tc-action, " +
+		"Signature=644be983de9a8a3f00db8eadaba61467c3b429e2215758ba897b738ca469fd26"
+	if auth != want {
+		t.Errorf("Authorization =\n%s\nwant\n%s", auth, want)
+	}
+
+	req, _ := http.NewRequest("POST", TencentCloudEndpoint, nil)
+	tc3ExampleCredential.sign(req, "dnspod.tencentcloudapi.com", "DescribeRecordList", tc3ExamplePayload, tc3ExampleTime)
+	for k, v := range map[string]string{
+		"Content-Type":   "application/json; charset=utf-8",
+		"X-TC-Action":    "DescribeRecordList",
+		"X-TC-Timestamp": "1551113065",
+		"X-TC-Version":   tc3Version,
+	} {
+		if got := req.Header.Get(k); got != v {
+			t.Errorf("%s = %q, want %q", k, got, v)
+		}
+	}
+	if got := req.Header.Get("Authorization"); !strings.Contains(got, "/2019-02-25/dnspod/tc3_request,") {
+		t.Errorf("Authorization = %q, want the dnspod scope", got)
+	}
+}
+
+//tc3Call is a call seen by a fake Tencent Cloud endpoint
+type tc3Call struct {
+	action string
+	body   map[string]interface{}
+	auth   string
+}
+
+//tc3Server answers each action with the JSON "Response" of answers, or "{}"
+func tc3Server(t *testing.T, answers map[string]string) (*Dnspod, *[]tc3Call) {
+	var calls []tc3Call
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c := tc3Call{action: r.Header.Get("X-TC-Action"), auth: r.Header.Get("Authorization")}
+		if err := json.NewDecoder(r.Body).Decode(&c.body); err != nil {
+			t.Errorf("%s: invalid body: %v", c.action, err)
+		}
+		calls = append(calls, c)
+		res, ok := answers[c.action]
+		if !ok {
+			res = "{}"
+		}
+		fmt.Fprintf(w, `{"Response": %s}`, res)
+	}))
+	t.Cleanup(s.Close)
+	d := NewTencentCloud("AKIDtest", "secret", WithBaseURL(s.URL), WithRetry(RetryPolicy{}), WithPreflight(false))
+	return d, &calls
+}
+
+func TestTC3Requests(t *testing.T) {
+	tests := []struct {
+		name   string
+		call   func(d *Dnspod) error
+		action string
+		body   string
+	}{
+		{"Record.List", func(d *Dnspod) error {
+			_, err := d.Record.List("example.com", RecordListOpt{SubDomain: "www", RecordType: RTypeA, RecordLine: LineTelecom, Offset: 10, Length: 20})
+			return err
+		}, "DescribeRecordList", `{"Domain":"example.com","Subdomain":"www","RecordType":"A","RecordLine":"电信","Offset":10,"Limit":20}`},
+		{"Record.Create", func(d *Dnspod) error {
+			_, err := d.Record.Create("example.com", RTypeMX, "mx.example.com.", RecordOpt{SubDomain: "@", MX: 10, TTL: 600})
+			return err
+		}, "CreateRecord", `{"Domain":"example.com","SubDomain":"@","RecordType":"MX","RecordLine":"默认","Value":"mx.example.com.","MX":10,"TTL":600,"Status":"ENABLE"}`},
+		{"Record.Status", func(d *Dnspod) error {
+			return d.Record.Status("example.com", 5, false)
+		}, "ModifyRecordStatus", `{"Domain":"example.com","RecordId":5,"Status":"DISABLE"}`},
+		{"Record.Ddns", func(d *Dnspod) error {
+			return d.Record.DDNS("example.com", 5, DDNSOpt{SubDomain: "home", Value: "192.0.2.1"})
+		}, "ModifyDynamicDNS", `{"Domain":"example.com","RecordId":5,"SubDomain":"home","RecordLine":"默认","Value":"192.0.2.1"}`},
+		{"Record.Line", func(d *Dnspod) error {
+			_, err := d.Record.Line("example.com", "DP_Free")
+			return err
+		}, "DescribeRecordLineList", `{"Domain":"example.com","DomainGrade":"DP_Free"}`},
+		{"Domain.Status", func(d *Dnspod) error {
+			return d.Domain.Status("example.com", true)
+		}, "ModifyDomainStatus", `{"Domain":"example.com","Status":"enable"}`},
+		{"Domain.List", func(d *Dnspod) error {
+			_, err := d.Domain.List(DomainListOpt{Type: DLTypeMine, Offset: 3, Length: 2, Keyword: "exa"})
+			return err
+		}, "DescribeDomainList", `{"Type":"MINE","Offset":3,"Limit":2,"Keyword":"exa"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, calls := tc3Server(t, nil)
+			if err := tt.call(d); err != nil {
+				t.Fatal(err)
+			}
+			if len(*calls) != 1 {
+				t.Fatalf("%d calls, want 1", len(*calls))
+			}
+			c := (*calls)[0]
+			var want map[string]interface{}
+			json.Unmarshal([]byte(tt.body), &want)
+			if c.action != tt.action || !reflect.DeepEqual(c.body, want) {
+				t.Errorf("sent %s %v, want %s %v", c.action, c.body, tt.action, want)
+			}
+			if !strings.HasPrefix(c.auth, "TC3-HMAC-SHA256 Credential=AKIDtest/") {
+				t.Errorf("Authorization = %q", c.auth)
+			}
+		})
+	}
+}
+
+func TestTC3Responses(t *testing.T) {
+	d, _ := tc3Server(t, map[string]string{
+		"DescribeRecordList": `{"RecordCountInfo": {"SubdomainCount": 1, "ListCount": 1, "TotalCount": 3},
+			"RecordList": [{"RecordId": 7, "Value": "192.0.2.1", "Status": "DISABLE", "UpdatedOn": "2024-01-02 03:04:05",
+			"Name": "www", "Line": "电信", "LineId": "10=0", "Type": "A", "Weight": 5, "TTL": 600, "MX": 0}]}`,
+		"DescribeRecord": `{"RecordInfo": {"Id": 7, "SubDomain": "www", "RecordType": "MX", "RecordLine": "默认", "RecordLineId": "0",
+			"Value": "mx.example.com.", "MX": 10, "TTL": 600, "Enabled": 1, "Remark": "mail"}}`,
+		"CreateRecord":           `{"RecordId": 8}`,
+		"DescribeRecordLineList": `{"LineList": [{"Name": "默认", "LineId": "0"}, {"Name": "电信", "LineId": "10=0"}]}`,
+		"DescribeRecordType":     `{"TypeList": ["A", "CNAME"]}`,
+		"DescribeDomain":         `{"DomainInfo": {"DomainId": 9, "Domain": "example.com", "Status": "ENABLE", "Grade": "DP_Free", "TTL": 600}}`,
+	})
+	list, err := d.Record.List("example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := list.Records[0]
+	if len(list.Records) != 1 || r.ID != 7 || r.Name != "www" || r.Line != "电信" || r.LineID != "10=0" || r.Enabled ||
+		r.Weight != 5 || list.Info.RecordTotal != 3 || list.Domain.Name != "example.com" {
+		t.Errorf("List = %+v", list)
+	}
+	if r, err := d.Record.Info("example.com", 7); err != nil || r.Type != "MX" || r.MX != 10 || !r.Enabled || r.Remark != "mail" {
+		t.Errorf("Info = %+v, %v", r, err)
+	}
+	if id, err := d.Record.Create("example.com", RTypeA, "192.0.2.1"); err != nil || id != 8 {
+		t.Errorf("Create = %d, %v", id, err)
+	}
+	lines, err := d.Record.Line("example.com", "DP_Free")
+	if err != nil || len(lines.Lines) != 2 || lines.IDs[LineTelecom] != "10=0" {
+		t.Errorf("Line = %+v, %v", lines, err)
+	}
+	if types, err := d.Record.Type("DP_Free"); err != nil || !reflect.DeepEqual(types, []RType{RTypeA, RTypeCNAME}) {
+		t.Errorf("Type = %v, %v", types, err)
+	}
+	if info, err := d.Domain.Info("example.com"); err != nil || info.ID != 9 || info.Name != "example.com" || !bool(info.Status) {
+		t.Errorf("Domain.Info = %+v, %v", info, err)
+	}
+}
+
+func TestTC3Errors(t *testing.T) {
+	domainList := func(d *Dnspod) error { _, err := d.Domain.List(); return err }
+	recordInfo := func(d *Dnspod) error { _, err := d.Record.Info("example.com", 1); return err }
+	tests := []struct {
+		action string
+		code   string
+		call   func(d *Dnspod) error
+		want   error
+	}{
+		{"DescribeDomainList", "AuthFailure.SignatureFailure", domainList, ErrAuth},
+		{"DescribeDomainList", "RequestLimitExceeded", domainList, ErrRateLimited},
+		{"DescribeDomainList", "UnauthorizedOperation", domainList, ErrPermission},
+		{"DescribeDomain", "InvalidParameter.DomainNotExist", func(d *Dnspod) error { _, err := d.Domain.Info("example.com"); return err }, ErrDomainNotFound},
+		{"CreateDomain", "InvalidParameter.DomainExists", func(d *Dnspod) error { _, err := d.Domain.Create("example.com"); return err }, ErrDomainExists},
+		{"DescribeRecord", "InvalidParameter.RecordIdInvalid", recordInfo, ErrRecordNotFound},
+		{"DescribeRecord", "ResourceNotFound.NoDataOfDomain", recordInfo, ErrDomainNotFound},
+		{"ModifyRecord", "OperationDenied.DomainLocked", func(d *Dnspod) error {
+			return d.Record.Modify("example.com", 1, RTypeA, "192.0.2.1")
+		}, ErrDomainLocked},
+	}
+	for _, tt := range tests {
+		d, _ := tc3Server(t, map[string]string{tt.action: `{"Error": {"Code": "` + tt.code + `", "Message": "failed"}}`})
+		err := tt.call(d)
+		var apiErr *APIError
+		if !errors.Is(err, tt.want) || !errors.As(err, &apiErr) || !strings.HasPrefix(apiErr.Message, tt.code+": ") {
+			t.Errorf("%s %s: err = %v, want %v", tt.action, tt.code, err, tt.want)
+		}
+	}
+
+	//the empty list of DescribeRecordList is an empty Record.List, not an error
+	d, _ := tc3Server(t, map[string]string{"DescribeRecordList": `{"Error": {"Code": "ResourceNotFound.NoDataOfRecord", "Message": "none"}}`})
+	if list, err := d.Record.List("example.com"); err != nil || len(list.Records) != 0 {
+		t.Errorf("empty List = %+v, %v", list, err)
+	}
+}
+
+func TestTC3DDNSWithoutValue(t *testing.T) {
+	d, calls := tc3Server(t, nil)
+	if err := d.Record.DDNS("example.com", 5); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("err = %v, want ErrUnsupported", err)
+	}
+	if len(*calls) != 0 {
+		t.Errorf("%d calls, want none", len(*calls))
+	}
+}
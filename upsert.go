@@ -0,0 +1,151 @@
+package dnspod
+
+import (
+	"context"
+	"errors"
+	"strings"
+)
+
+//ErrMultipleRecords there are several matching records and none of them has the value
+var ErrMultipleRecords = errors.New("dnspod: multiple records match, can not choose one")
+
+//UpsertAction is the action taken by Record.Upsert
+type UpsertAction string
+
+const (
+	//UpsertCreated a new record was created
+	UpsertCreated UpsertAction = "created"
+	//UpsertModified the existing record was modified
+	UpsertModified UpsertAction = "modified"
+	//UpsertUnchanged the existing record was already identical
+	UpsertUnchanged UpsertAction = "unchanged"
+)
+
+//UpsertResult is the result of Record.Upsert
+type UpsertResult struct {
+	Action   UpsertAction
+	RecordID int64
+}
+
+//Upsert used to set the record of subDomain/recordType/opt.RecordLine to value:
+//creates it when absent, modifies it when different, does nothing when identical.
+//When several records match (round robin), the one with the same value is kept,
+//otherwise ErrMultipleRecords is returned.
+//domain: 		Domain name
+//subDomain:	The host record, such as "www", "@" for the domain itself, overrides opt.SubDomain
+//recordType:	Record type, uppercase , you can use RType series constants
+//value: 		The value of the record(ip/mx/url...)
+//opt:			The other optional arg, TTL/Weight are only compared when set
+//The result is the zero UpsertResult when an error is returned.
+func (p *RecordAPI) Upsert(domain, subDomain string, recordType RType, value string, opt ...RecordOpt) (UpsertResult, error) {
+	return p.UpsertContext(context.Background(), domain, subDomain, recordType, value, opt...)
+}
+
+//UpsertContext is like Upsert but carries ctx for cancellation and deadlines
+func (p *RecordAPI) UpsertContext(ctx context.Context, domain, subDomain string, recordType RType, value string, opt ...RecordOpt) (UpsertResult, error) {
+	return UpsertRecord(ctx, p, domain, subDomain, recordType, value, opt...)
+}
+
+//UpsertRecord is Record.Upsert on the records served by s
+func UpsertRecord(ctx context.Context, s RecordService, domain, subDomain string, recordType RType, value string, opt ...RecordOpt) (UpsertResult, error) {
+	var o RecordOpt
+	if len(opt) > 0 {
+		o = opt[0]
+	}
+	if subDomain == "" {
+		subDomain = "@"
+	}
+	o.SubDomain = subDomain
+	if o.RecordLine == "" {
+		o.RecordLine = LineDefault
+	}
+	list, err := s.ListContext(ctx, domain, RecordListOpt{
+		SubDomain:  subDomain,
+		RecordType: recordType,
+		RecordLine: o.RecordLine,
+	})
+	if err != nil {
+		return UpsertResult{}, err
+	}
+	var matches []Record
+	for _, r := range list.Records {
+		if strings.EqualFold(r.Name, subDomain) && r.Type == string(recordType) && r.Line == string(o.RecordLine) {
+			matches = append(matches, r)
+		}
+	}
+	var target *Record
+	for i := range matches {
+		if SameValue(recordType, matches[i].Value, value) {
+			target = &matches[i]
+			break
+		}
+	}
+	switch {
+	case target != nil:
+	case len(matches) == 0:
+		id, err := s.CreateContext(ctx, domain, recordType, value, o)
+		if err != nil {
+			return UpsertResult{}, err
+		}
+		return UpsertResult{Action: UpsertCreated, RecordID: id}, nil
+	case len(matches) == 1:
+		target = &matches[0]
+	default:
+		return UpsertResult{}, ErrMultipleRecords
+	}
+	if SameValue(recordType, target.Value, value) && sameOpt(*target, o) {
+		return UpsertResult{Action: UpsertUnchanged, RecordID: target.ID}, nil
+	}
+	//keep the options that are not set
+	if o.TTL == 0 {
+		o.TTL = target.TTL
+	}
+	if o.MX == 0 {
+		o.MX = target.MX
+	}
+	if o.Weight == 0 {
+		o.Weight = target.Weight
+	}
+	if err = s.ModifyContext(ctx, domain, target.ID, recordType, value, o); err != nil {
+		return UpsertResult{}, err
+	}
+	return UpsertResult{Action: UpsertModified, RecordID: target.ID}, nil
+}
+
+//sameOpt reports whether the record already has the options that are set
+func sameOpt(r Record, o RecordOpt) bool {
+	return bool(r.Enabled) != o.Disable &&
+		(o.TTL == 0 || o.TTL == r.TTL) &&
+		(o.MX == 0 || o.MX == r.MX) &&
+		(o.Weight == 0 || o.Weight == r.Weight)
+}
+
+//SameValue reports whether two values of a record type are equal,
+//the domain names of CNAME/MX/NS/SRV/HTTPS/SVCB and the tag of CAA are compared
+//case-insensitively and ignoring the trailing dot, the quoted strings of TXT/SPF are joined
+func SameValue(recordType RType, a, b string) bool {
+	switch recordType {
+	case RTypeCNAME, RTypeMX, RTypeNS, RTypeSRV:
+		return strings.EqualFold(strings.TrimSuffix(a, "."), strings.TrimSuffix(b, "."))
+	case RTypeCAA:
+		//flags tag "value"
+		va, errA := ParseCAAValue(a)
+		vb, errB := ParseCAAValue(b)
+		if errA != nil || errB != nil {
+			return a == b
+		}
+		return va == vb
+	case RTypeTXT, RTypeSPF:
+		//a long value is quoted strings of 255 bytes
+		return a == b || unquoteTXT(a) == unquoteTXT(b)
+	case RTypeHTTPS, RTypeSVCB:
+		//priority target params...
+		fa, fb := strings.Fields(a), strings.Fields(b)
+		if len(fa) < 2 || len(fa) != len(fb) || fa[0] != fb[0] ||
+			!strings.EqualFold(strings.TrimSuffix(fa[1], "."), strings.TrimSuffix(fb[1], ".")) {
+			return a == b
+		}
+		return strings.Join(fa[2:], " ") == strings.Join(fb[2:], " ")
+	}
+	return a == b
+}
@@ -0,0 +1,63 @@
+package dnspod_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/bigemon/dnspod"
+)
+
+func TestUpsert(t *testing.T) {
+	s := newServer(t)
+	s.AddDomain("example.com")
+	calls := map[string]int{}
+	d := s.Dnspod(dnspod.WithMiddleware(countCalls(calls)))
+
+	res, err := d.Record.Upsert("example.com", "www", dnspod.RTypeA, "192.0.2.1")
+	if err != nil || res.Action != dnspod.UpsertCreated || res.RecordID == 0 {
+		t.Fatalf("first Upsert = %+v, %v", res, err)
+	}
+	id := res.RecordID
+	if res, err = d.Record.Upsert("example.com", "www", dnspod.RTypeA, "192.0.2.1"); err != nil || res != (dnspod.UpsertResult{Action: dnspod.UpsertUnchanged, RecordID: id}) {
+		t.Errorf("same value: Upsert = %+v, %v", res, err)
+	}
+	if calls["Record.Modify"] != 0 || calls["Record.Create"] != 1 {
+		t.Errorf("calls = %v", calls)
+	}
+	if res, err = d.Record.Upsert("example.com", "www", dnspod.RTypeA, "192.0.2.2", dnspod.RecordOpt{TTL: 3600}); err != nil || res != (dnspod.UpsertResult{Action: dnspod.UpsertModified, RecordID: id}) {
+		t.Errorf("new value: Upsert = %+v, %v", res, err)
+	}
+	if r, _ := d.Record.Info("example.com", id); r.Value != "192.0.2.2" || r.TTL != 3600 || r.Line != string(dnspod.LineDefault) {
+		t.Errorf("Info after Upsert = %+v", r)
+	}
+	//a TTL not set is kept
+	if res, err = d.Record.Upsert("example.com", "www", dnspod.RTypeA, "192.0.2.2"); err != nil || res.Action != dnspod.UpsertUnchanged {
+		t.Errorf("TTL not set: Upsert = %+v, %v", res, err)
+	}
+
+	//round robin: the record with the value is kept, otherwise there is no way to choose
+	d.Record.Create("example.com", dnspod.RTypeA, "192.0.2.3", dnspod.RecordOpt{SubDomain: "www", TTL: 3600})
+	if res, err = d.Record.Upsert("example.com", "www", dnspod.RTypeA, "192.0.2.2"); err != nil || res.Action != dnspod.UpsertUnchanged || res.RecordID != id {
+		t.Errorf("round robin: Upsert = %+v, %v", res, err)
+	}
+	if res, err = d.Record.Upsert("example.com", "www", dnspod.RTypeA, "192.0.2.4"); !errors.Is(err, dnspod.ErrMultipleRecords) || res != (dnspod.UpsertResult{}) {
+		t.Errorf("round robin, new value: Upsert = %+v, %v, want ErrMultipleRecords", res, err)
+	}
+}
+
+func TestUpsertError(t *testing.T) {
+	s := newServer(t)
+	s.AddDomain("example.com")
+	d := s.Dnspod()
+	for _, endpoint := range []string{"Record.List", "Record.Create"} {
+		s.FailNext(endpoint, -2, "busy")
+		if res, err := d.Record.Upsert("example.com", "www", dnspod.RTypeA, "192.0.2.1"); err == nil || res != (dnspod.UpsertResult{}) {
+			t.Errorf("%s fails: Upsert = %+v, %v, want the zero result", endpoint, res, err)
+		}
+	}
+	d.Record.Upsert("example.com", "www", dnspod.RTypeA, "192.0.2.1")
+	s.FailNext("Record.Modify", -2, "busy")
+	if res, err := d.Record.Upsert("example.com", "www", dnspod.RTypeA, "192.0.2.2"); err == nil || res != (dnspod.UpsertResult{}) {
+		t.Errorf("Record.Modify fails: Upsert = %+v, %v, want the zero result", res, err)
+	}
+}
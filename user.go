@@ -1,9 +1,8 @@
 package dnspod
 
 import (
+	"context"
 	"encoding/json"
-	"errors"
-	"net/http"
 	"net/url"
 )
 
@@ -27,16 +26,18 @@ type User struct {
 
 //UserAPI packaged some dnspod user APIs
 type UserAPI struct {
-	loginToken string
-	client     *http.Client
+	c *client
 }
 
 //Detail Get Account Detail
 func (p *UserAPI) Detail() (u User, err error) {
+	return p.DetailContext(context.Background())
+}
+
+//DetailContext is like Detail but carries ctx for cancellation and deadlines
+func (p *UserAPI) DetailContext(ctx context.Context) (u User, err error) {
 	params := url.Values{}
-	params.Set("login_token", p.loginToken)
-	params.Set("format", "json")
-	res, err := simpleHTTP(p.client, "POST", "https://dnsapi.cn/User.Detail", params)
+	res, err := p.c.post(ctx, "User.Detail", params)
 	if err != nil {
 		return
 	}
@@ -50,7 +51,7 @@ func (p *UserAPI) Detail() (u User, err error) {
 		return
 	}
 	if jsonRes.Status.Code != 1 {
-		return u, errors.New(jsonRes.Status.Message)
+		return u, newAPIError("User.Detail", params, jsonRes.Status)
 	}
 	return jsonRes.Info.User, nil
 }
@@ -65,10 +66,12 @@ type ModifyDetailOpt struct {
 //ModifyDetail use to modify detail of user
 //opt:			The details you want to modify (RealName/Nick/Telephone)
 func (p *UserAPI) ModifyDetail(opt ModifyDetailOpt) (err error) {
-	params := url.Values{}
-	params.Set("login_token", p.loginToken)
-	params.Set("format", "json")
+	return p.ModifyDetailContext(context.Background(), opt)
+}
 
+//ModifyDetailContext is like ModifyDetail but carries ctx for cancellation and deadlines
+func (p *UserAPI) ModifyDetailContext(ctx context.Context, opt ModifyDetailOpt) (err error) {
+	params := url.Values{}
 	if opt.RealName != "" {
 		params.Set("real_name", opt.RealName)
 	}
@@ -79,7 +82,7 @@ func (p *UserAPI) ModifyDetail(opt ModifyDetailOpt) (err error) {
 		params.Set("telephone", opt.Telephone)
 	}
 
-	res, err := simpleHTTP(p.client, "POST", "https://dnsapi.cn/User.Modify", params)
+	res, err := p.c.post(ctx, "User.Modify", params)
 	if err != nil {
 		return
 	}
@@ -90,7 +93,7 @@ func (p *UserAPI) ModifyDetail(opt ModifyDetailOpt) (err error) {
 		return
 	}
 	if jsonRes.Status.Code != 1 {
-		return errors.New(jsonRes.Status.Message)
+		return newAPIError("User.Modify", params, jsonRes.Status)
 	}
 	return nil
 }
@@ -99,14 +102,16 @@ func (p *UserAPI) ModifyDetail(opt ModifyDetailOpt) (err error) {
 //oldPwd:		Old password
 //newPwd:		The new password you want to change
 func (p *UserAPI) ModifyPassword(oldPwd, newPwd string) (err error) {
-	params := url.Values{}
-	params.Set("login_token", p.loginToken)
-	params.Set("format", "json")
+	return p.ModifyPasswordContext(context.Background(), oldPwd, newPwd)
+}
 
+//ModifyPasswordContext is like ModifyPassword but carries ctx for cancellation and deadlines
+func (p *UserAPI) ModifyPasswordContext(ctx context.Context, oldPwd, newPwd string) (err error) {
+	params := url.Values{}
 	params.Set("old_password", oldPwd)
 	params.Set("new_password", newPwd)
 
-	res, err := simpleHTTP(p.client, "POST", "https://dnsapi.cn/Userpasswd.Modify", params)
+	res, err := p.c.post(ctx, "Userpasswd.Modify", params)
 	if err != nil {
 		return
 	}
@@ -117,22 +122,24 @@ func (p *UserAPI) ModifyPassword(oldPwd, newPwd string) (err error) {
 		return
 	}
 	if jsonRes.Status.Code != 1 {
-		return errors.New(jsonRes.Status.Message)
+		return newAPIError("Userpasswd.Modify", params, jsonRes.Status)
 	}
 	return nil
 }
 
 //ModifyEmail use to modify email of user
 func (p *UserAPI) ModifyEmail(pwd, oldEmail, newEmail string) (err error) {
-	params := url.Values{}
-	params.Set("login_token", p.loginToken)
-	params.Set("format", "json")
+	return p.ModifyEmailContext(context.Background(), pwd, oldEmail, newEmail)
+}
 
+//ModifyEmailContext is like ModifyEmail but carries ctx for cancellation and deadlines
+func (p *UserAPI) ModifyEmailContext(ctx context.Context, pwd, oldEmail, newEmail string) (err error) {
+	params := url.Values{}
 	params.Set("password", pwd)
 	params.Set("old_email", oldEmail)
 	params.Set("new_email", newEmail)
 
-	res, err := simpleHTTP(p.client, "POST", "https://dnsapi.cn/Useremail.Modify", params)
+	res, err := p.c.post(ctx, "Useremail.Modify", params)
 	if err != nil {
 		return
 	}
@@ -143,7 +150,7 @@ func (p *UserAPI) ModifyEmail(pwd, oldEmail, newEmail string) (err error) {
 		return
 	}
 	if jsonRes.Status.Code != 1 {
-		return errors.New(jsonRes.Status.Message)
+		return newAPIError("Useremail.Modify", params, jsonRes.Status)
 	}
 	return nil
 }
@@ -156,12 +163,14 @@ type VerifyInfo struct {
 
 //PhoneVerify Get cell phone Verification code and binding information
 func (p *UserAPI) PhoneVerify(phone string) (v VerifyInfo, err error) {
-	params := url.Values{}
-	params.Set("login_token", p.loginToken)
-	params.Set("format", "json")
+	return p.PhoneVerifyContext(context.Background(), phone)
+}
 
+//PhoneVerifyContext is like PhoneVerify but carries ctx for cancellation and deadlines
+func (p *UserAPI) PhoneVerifyContext(ctx context.Context, phone string) (v VerifyInfo, err error) {
+	params := url.Values{}
 	params.Set("telephone", phone)
-	res, err := simpleHTTP(p.client, "POST", "https://dnsapi.cn/Telephoneverify.Code", params)
+	res, err := p.c.post(ctx, "Telephoneverify.Code", params)
 	if err != nil {
 		return
 	}
@@ -173,18 +182,20 @@ func (p *UserAPI) PhoneVerify(phone string) (v VerifyInfo, err error) {
 		return
 	}
 	if jsonRes.Status.Code != 1 {
-		return v, errors.New(jsonRes.Status.Message)
+		return v, newAPIError("Telephoneverify.Code", params, jsonRes.Status)
 	}
 	return jsonRes.Info, nil
 }
 
 //Log get action logs
 func (p *UserAPI) Log() (log []string, err error) {
-	params := url.Values{}
-	params.Set("login_token", p.loginToken)
-	params.Set("format", "json")
+	return p.LogContext(context.Background())
+}
 
-	res, err := simpleHTTP(p.client, "POST", "https://dnsapi.cn/User.Log", params)
+//LogContext is like Log but carries ctx for cancellation and deadlines
+func (p *UserAPI) LogContext(ctx context.Context) (log []string, err error) {
+	params := url.Values{}
+	res, err := p.c.post(ctx, "User.Log", params)
 	if err != nil {
 		return
 	}
@@ -196,7 +207,7 @@ func (p *UserAPI) Log() (log []string, err error) {
 		return
 	}
 	if jsonRes.Status.Code != 1 {
-		return log, errors.New(jsonRes.Status.Message)
+		return log, newAPIError("User.Log", params, jsonRes.Status)
 	}
 	return jsonRes.Log, nil
 }
@@ -0,0 +1,397 @@
+package dnspod
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+//RecordValue is a structured record value, see MXValue, SRVValue, CAAValue and TXTValue.
+//Record.CreateValue/ModifyValue validate it before sending Value() to dnspod.
+type RecordValue interface {
+	RecordType() RType
+	Value() string //The value sent to dnspod
+	Validate() error
+}
+
+//ValueError is returned when a field of a RecordValue is invalid
+type ValueError struct {
+	Type   RType
+	Field  string
+	Reason string
+}
+
+//Error interface
+func (e *ValueError) Error() string {
+	if e.Type == "" {
+		return fmt.Sprintf("dnspod: invalid value: %s %s", e.Field, e.Reason)
+	}
+	return fmt.Sprintf("dnspod: invalid %s value: %s %s", e.Type, e.Field, e.Reason)
+}
+
+//validHost reports whether s is a domain name, "." is accepted when root is set
+func validHost(s string, root bool) bool {
+	if s == "." {
+		return root
+	}
+	s = strings.TrimSuffix(s, ".")
+	if s == "" || len(s) > 253 {
+		return false
+	}
+	for _, label := range strings.Split(s, ".") {
+		if label == "" || len(label) > 63 || strings.ContainsAny(label, " \t\"\\") {
+			return false
+		}
+	}
+	return true
+}
+
+//---------------------------------------------------------------------------------
+
+//MXValue is the value of a MX record, Priority is sent as RecordOpt.MX
+type MXValue struct {
+	Priority int    //Range 1-20, the lower is tried first
+	Host     string //The mail server
+}
+
+//ParseMXValue parses "priority host", such as "10 mx.example.com."
+func ParseMXValue(s string) (v MXValue, err error) {
+	f := strings.Fields(s)
+	if len(f) != 2 {
+		return v, &ValueError{RTypeMX, "value", fmt.Sprintf("%q is not \"priority host\"", s)}
+	}
+	if v.Priority, err = strconv.Atoi(f[0]); err != nil {
+		return v, &ValueError{RTypeMX, "priority", fmt.Sprintf("%q is not a number", f[0])}
+	}
+	v.Host = f[1]
+	return v, v.Validate()
+}
+
+//RecordType interface
+func (v MXValue) RecordType() RType {
+	return RTypeMX
+}
+
+//Value interface, the priority is not part of the dnspod value
+func (v MXValue) Value() string {
+	return v.Host
+}
+
+//String returns "priority host"
+func (v MXValue) String() string {
+	return strconv.Itoa(v.Priority) + " " + v.Host
+}
+
+//Validate interface
+func (v MXValue) Validate() error {
+	if v.Priority < 1 || v.Priority > 20 {
+		return &ValueError{RTypeMX, "priority", fmt.Sprintf("%d is out of range 1-20", v.Priority)}
+	}
+	if !validHost(v.Host, false) {
+		return &ValueError{RTypeMX, "host", fmt.Sprintf("%q is not a domain name", v.Host)}
+	}
+	return nil
+}
+
+//---------------------------------------------------------------------------------
+
+//SRVValue is the value of a SRV record
+type SRVValue struct {
+	Priority int    //Range 0-65535, the lower is tried first
+	Weight   int    //Range 0-65535, the share among the targets of the same priority
+	Port     int    //Range 0-65535
+	Target   string //The host providing the service, "." means the service is not available
+}
+
+//ParseSRVValue parses "priority weight port target", such as "10 5 5060 sip.example.com."
+func ParseSRVValue(s string) (v SRVValue, err error) {
+	f := strings.Fields(s)
+	if len(f) != 4 {
+		return v, &ValueError{RTypeSRV, "value", fmt.Sprintf("%q is not \"priority weight port target\"", s)}
+	}
+	for i, p := range []*int{&v.Priority, &v.Weight, &v.Port} {
+		if *p, err = strconv.Atoi(f[i]); err != nil {
+			return v, &ValueError{RTypeSRV, [...]string{"priority", "weight", "port"}[i], fmt.Sprintf("%q is not a number", f[i])}
+		}
+	}
+	v.Target = f[3]
+	return v, v.Validate()
+}
+
+//RecordType interface
+func (v SRVValue) RecordType() RType {
+	return RTypeSRV
+}
+
+//Value interface
+func (v SRVValue) Value() string {
+	return v.String()
+}
+
+//String returns "priority weight port target"
+func (v SRVValue) String() string {
+	return fmt.Sprintf("%d %d %d %s", v.Priority, v.Weight, v.Port, v.Target)
+}
+
+//Validate interface
+func (v SRVValue) Validate() error {
+	for _, f := range []struct {
+		name string
+		n    int
+	}{{"priority", v.Priority}, {"weight", v.Weight}, {"port", v.Port}} {
+		if f.n < 0 || f.n > 65535 {
+			return &ValueError{RTypeSRV, f.name, fmt.Sprintf("%d is out of range 0-65535", f.n)}
+		}
+	}
+	if !validHost(v.Target, true) {
+		return &ValueError{RTypeSRV, "target", fmt.Sprintf("%q is not a domain name", v.Target)}
+	}
+	return nil
+}
+
+//---------------------------------------------------------------------------------
+
+//CAAValue is the value of a CAA record
+type CAAValue struct {
+	Flags int    //0, or 128 when the CA must understand the tag (critical)
+	Tag   string //"issue", "issuewild" or "iodef"
+	Data  string //The property value: the CA domain, such as "letsencrypt.org", or the iodef URL
+}
+
+//ParseCAAValue parses "flags tag \"value\"", such as `0 issue "letsencrypt.org"`
+func ParseCAAValue(s string) (v CAAValue, err error) {
+	f := strings.Fields(s)
+	if len(f) < 3 {
+		return v, &ValueError{RTypeCAA, "value", fmt.Sprintf("%q is not \"flags tag value\"", s)}
+	}
+	if v.Flags, err = strconv.Atoi(f[0]); err != nil {
+		return v, &ValueError{RTypeCAA, "flags", fmt.Sprintf("%q is not a number", f[0])}
+	}
+	v.Tag = strings.ToLower(f[1])
+	//the data may contain blanks, it is the rest of s after the flags and the tag
+	v.Data = strings.TrimSpace(s)
+	for _, field := range f[:2] {
+		v.Data = strings.TrimSpace(strings.TrimPrefix(v.Data, field))
+	}
+	if len(v.Data) >= 2 && v.Data[0] == '"' && v.Data[len(v.Data)-1] == '"' {
+		v.Data = v.Data[1 : len(v.Data)-1]
+	}
+	return v, v.Validate()
+}
+
+//RecordType interface
+func (v CAAValue) RecordType() RType {
+	return RTypeCAA
+}
+
+//Value interface
+func (v CAAValue) Value() string {
+	return v.String()
+}
+
+//String returns "flags tag \"data\""
+func (v CAAValue) String() string {
+	return fmt.Sprintf("%d %s \"%s\"", v.Flags, v.Tag, v.Data)
+}
+
+//Validate interface
+func (v CAAValue) Validate() error {
+	if v.Flags != 0 && v.Flags != 128 {
+		return &ValueError{RTypeCAA, "flags", fmt.Sprintf("%d is not 0 or 128", v.Flags)}
+	}
+	switch v.Tag {
+	case "issue", "issuewild", "iodef":
+	default:
+		return &ValueError{RTypeCAA, "tag", fmt.Sprintf("%q is not issue, issuewild or iodef", v.Tag)}
+	}
+	if strings.ContainsAny(v.Data, "\"\n") {
+		return &ValueError{RTypeCAA, "data", fmt.Sprintf("%q contains a quote or a newline", v.Data)}
+	}
+	if v.Tag == "iodef" && !strings.HasPrefix(v.Data, "mailto:") && !strings.HasPrefix(v.Data, "http://") &&
+		!strings.HasPrefix(v.Data, "https://") {
+		return &ValueError{RTypeCAA, "data", fmt.Sprintf("%q is not a mailto: or http(s) URL", v.Data)}
+	}
+	return nil
+}
+
+//---------------------------------------------------------------------------------
+
+//TXTValue is the value of a TXT record, a text longer than 255 bytes is sent as quoted strings of 255 bytes
+type TXTValue struct {
+	Text string
+}
+
+//ParseTXTValue parses a TXT value, the quoted strings ("a" "b") are joined
+func ParseTXTValue(s string) (v TXTValue, err error) {
+	if !strings.HasPrefix(s, `"`) {
+		return TXTValue{s}, nil
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); {
+		switch {
+		case s[i] == ' ' || s[i] == '\t':
+			i++
+			continue
+		case s[i] != '"':
+			return v, &ValueError{RTypeTXT, "value", fmt.Sprintf("%q has text outside of the quotes", s)}
+		}
+		i++
+		closed := false
+		for i < len(s) {
+			c := s[i]
+			i++
+			if c == '\\' && i < len(s) {
+				b.WriteByte(s[i])
+				i++
+				continue
+			}
+			if c == '"' {
+				closed = true
+				break
+			}
+			b.WriteByte(c)
+		}
+		if !closed {
+			return v, &ValueError{RTypeTXT, "value", fmt.Sprintf("%q has an unterminated quote", s)}
+		}
+	}
+	return TXTValue{b.String()}, nil
+}
+
+//RecordType interface
+func (v TXTValue) RecordType() RType {
+	return RTypeTXT
+}
+
+//Chunks splits the text into strings of 255 bytes at most, a UTF-8 character is never split
+func (v TXTValue) Chunks() []string {
+	s := v.Text
+	var chunks []string
+	for len(s) > 255 {
+		n := 255
+		for n > 0 && !utf8.RuneStart(s[n]) {
+			n--
+		}
+		if n == 0 {
+			//not UTF-8
+			n = 255
+		}
+		chunks = append(chunks, s[:n])
+		s = s[n:]
+	}
+	return append(chunks, s)
+}
+
+//Value interface, the text itself when it fits in a string, the quoted chunks otherwise.
+//A text starting with a quote is quoted too, so that it is not taken for the quoted form.
+func (v TXTValue) Value() string {
+	if len(v.Text) <= 255 && !strings.HasPrefix(v.Text, `"`) {
+		return v.Text
+	}
+	return v.quoted()
+}
+
+//String returns the value, ParseTXTValue(v.String()) gives v back
+func (v TXTValue) String() string {
+	return v.Value()
+}
+
+//quoted returns the chunks as quoted strings, such as "a" "b"
+func (v TXTValue) quoted() string {
+	chunks := v.Chunks()
+	for i, c := range chunks {
+		c = strings.ReplaceAll(c, `\`, `\\`)
+		chunks[i] = `"` + strings.ReplaceAll(c, `"`, `\"`) + `"`
+	}
+	return strings.Join(chunks, " ")
+}
+
+//unquoteTXT returns the text of a TXT value, which is quoted strings when it was long
+func unquoteTXT(s string) string {
+	if v, err := ParseTXTValue(s); err == nil {
+		return v.Text
+	}
+	return s
+}
+
+//Validate interface
+func (v TXTValue) Validate() error {
+	if v.Text == "" {
+		return &ValueError{RTypeTXT, "text", "is empty"}
+	}
+	return nil
+}
+
+//ParseRecordValue parses the value of a record got from Record.Info or Record.List,
+//the types without a structured value return a *ValueError
+func ParseRecordValue(r Record) (RecordValue, error) {
+	switch RType(r.Type) {
+	case RTypeMX:
+		v := MXValue{Priority: r.MX, Host: r.Value}
+		return v, v.Validate()
+	case RTypeSRV:
+		return ParseSRVValue(r.Value)
+	case RTypeCAA:
+		return ParseCAAValue(r.Value)
+	case RTypeTXT:
+		return ParseTXTValue(r.Value)
+	}
+	return nil, &ValueError{RType(r.Type), "type", "has no structured value"}
+}
+
+//---------------------------------------------------------------------------------
+
+//CreateValue used to create a record of a structured value, the value is validated first
+//domain: 		Domain name
+//v:			The value, such as SRVValue{10, 5, 5060, "sip.example.com."}
+//opt:			The other optional arg, opt.MX is set from MXValue
+func (p *RecordAPI) CreateValue(domain string, v RecordValue, opt ...RecordOpt) (id int64, err error) {
+	return p.CreateValueContext(context.Background(), domain, v, opt...)
+}
+
+//CreateValueContext is like CreateValue but carries ctx for cancellation and deadlines
+func (p *RecordAPI) CreateValueContext(ctx context.Context, domain string, v RecordValue, opt ...RecordOpt) (id int64, err error) {
+	o, err := valueOpt(v, opt)
+	if err != nil {
+		return
+	}
+	return p.CreateContext(ctx, domain, v.RecordType(), v.Value(), o)
+}
+
+//ModifyValue used to modify a record into a structured value, the value is validated first
+//domain: 		Domain name
+//recordID:		The specified record ID that you want to modify
+//v:			The value, such as MXValue{10, "mx.example.com."}
+//opt:			The other optional arg, opt.MX is set from MXValue
+func (p *RecordAPI) ModifyValue(domain string, recordID int64, v RecordValue, opt ...RecordOpt) (err error) {
+	return p.ModifyValueContext(context.Background(), domain, recordID, v, opt...)
+}
+
+//ModifyValueContext is like ModifyValue but carries ctx for cancellation and deadlines
+func (p *RecordAPI) ModifyValueContext(ctx context.Context, domain string, recordID int64, v RecordValue, opt ...RecordOpt) (err error) {
+	o, err := valueOpt(v, opt)
+	if err != nil {
+		return
+	}
+	return p.ModifyContext(ctx, domain, recordID, v.RecordType(), v.Value(), o)
+}
+
+//valueOpt validates v and returns the RecordOpt to send it with
+func valueOpt(v RecordValue, opt []RecordOpt) (o RecordOpt, err error) {
+	if len(opt) > 0 {
+		o = opt[0]
+	}
+	if rv := reflect.ValueOf(v); v == nil || rv.Kind() == reflect.Ptr && rv.IsNil() {
+		return o, &ValueError{Field: "value", Reason: "is nil"}
+	}
+	if err = v.Validate(); err != nil {
+		return
+	}
+	if mx, ok := v.(MXValue); ok {
+		o.MX = mx.Priority
+	}
+	return o, nil
+}
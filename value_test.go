@@ -0,0 +1,145 @@
+package dnspod
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestParseValues(t *testing.T) {
+	tests := []struct {
+		s    string
+		want RecordValue
+	}{
+		{"10 mx.example.com.", MXValue{10, "mx.example.com."}},
+		{"10 5 5060 sip.example.com.", SRVValue{10, 5, 5060, "sip.example.com."}},
+		{"0 0 0 .", SRVValue{0, 0, 0, "."}},
+		{`0 issue "letsencrypt.org"`, CAAValue{0, "issue", "letsencrypt.org"}},
+		{`128 IODEF "mailto:security@example.com"`, CAAValue{128, "iodef", "mailto:security@example.com"}},
+		{"0  issue\t \"letsencrypt.org\" ", CAAValue{0, "issue", "letsencrypt.org"}},
+		{` 0 issue "ca.example; account=1"`, CAAValue{0, "issue", "ca.example; account=1"}},
+		{"v=spf1 -all", TXTValue{"v=spf1 -all"}},
+		{`"a\"b" "c"`, TXTValue{`a"bc`}},
+	}
+	for _, tt := range tests {
+		var got RecordValue
+		var err error
+		switch tt.want.(type) {
+		case MXValue:
+			got, err = ParseMXValue(tt.s)
+		case SRVValue:
+			got, err = ParseSRVValue(tt.s)
+		case CAAValue:
+			got, err = ParseCAAValue(tt.s)
+		case TXTValue:
+			got, err = ParseTXTValue(tt.s)
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("parse %q = %v, %v, want %v", tt.s, got, err, tt.want)
+		}
+	}
+}
+
+func TestValueStringRoundTrip(t *testing.T) {
+	values := []RecordValue{
+		MXValue{20, "mx.example.com."},
+		SRVValue{1, 2, 3, "target.example.com."},
+		CAAValue{0, "issuewild", ";"},
+		TXTValue{"plain"},
+		TXTValue{`"quoted"`},
+		TXTValue{`back\slash "and" quotes`},
+		TXTValue{strings.Repeat("x", 600)},
+	}
+	for _, v := range values {
+		s := v.(interface{ String() string }).String()
+		var got RecordValue
+		var err error
+		switch v.(type) {
+		case MXValue:
+			got, err = ParseMXValue(s)
+		case SRVValue:
+			got, err = ParseSRVValue(s)
+		case CAAValue:
+			got, err = ParseCAAValue(s)
+		case TXTValue:
+			got, err = ParseTXTValue(s)
+		}
+		if err != nil || got != v {
+			t.Errorf("parse(%q) = %v, %v, want %v", s, got, err, v)
+		}
+	}
+}
+
+func TestValueValidate(t *testing.T) {
+	invalid := []RecordValue{
+		MXValue{0, "mx.example.com."},
+		MXValue{21, "mx.example.com."},
+		MXValue{10, "."},
+		SRVValue{1, 1, 65536, "sip.example.com."},
+		SRVValue{-1, 1, 1, "sip.example.com."},
+		SRVValue{1, 1, 1, "a..b"},
+		CAAValue{1, "issue", "ca.example"},
+		CAAValue{0, "foo", "ca.example"},
+		CAAValue{0, "iodef", "security@example.com"},
+		CAAValue{0, "issue", `a"b`},
+		TXTValue{""},
+	}
+	for _, v := range invalid {
+		var ve *ValueError
+		if err := v.Validate(); !errors.As(err, &ve) || ve.Type != v.RecordType() {
+			t.Errorf("%#v: Validate() = %v, want a *ValueError", v, err)
+		}
+	}
+	for _, s := range []string{"10", "x mx.example.com.", "1 2 3", "1 2 x target."} {
+		if _, err := ParseSRVValue(s); err == nil {
+			t.Errorf("ParseSRVValue(%q) succeeded", s)
+		}
+	}
+	if _, err := ParseTXTValue(`"unterminated`); err == nil {
+		t.Error("ParseTXTValue accepted an unterminated quote")
+	}
+}
+
+func TestTXTValueChunks(t *testing.T) {
+	//é is 2 bytes, a chunk of 255 bytes would split it
+	text := strings.Repeat("é", 200)
+	chunks := TXTValue{text}.Chunks()
+	if strings.Join(chunks, "") != text {
+		t.Fatal("the chunks do not join to the text")
+	}
+	for _, c := range chunks {
+		if len(c) > 255 || !utf8.ValidString(c) {
+			t.Errorf("chunk of %d bytes, valid UTF-8 %v", len(c), utf8.ValidString(c))
+		}
+	}
+	if v := (TXTValue{"short"}).Value(); v != "short" {
+		t.Errorf("Value() = %q, want the text", v)
+	}
+	if v := (TXTValue{strings.Repeat("a", 256)}).Value(); v != `"`+strings.Repeat("a", 255)+`" "a"` {
+		t.Errorf("Value() = %q, want 2 quoted strings", v)
+	}
+}
+
+func TestNilValue(t *testing.T) {
+	d := NewDnspod("1,token", WithBaseURL("http://127.0.0.1:1"), WithRetry(RetryPolicy{}))
+	var ve *ValueError
+	if _, err := d.Record.CreateValue("example.com", nil); !errors.As(err, &ve) {
+		t.Errorf("CreateValue(nil) = %v, want a *ValueError", err)
+	}
+	if err := d.Record.ModifyValue("example.com", 1, (*MXValue)(nil)); !errors.As(err, &ve) {
+		t.Errorf("ModifyValue of a nil *MXValue = %v, want a *ValueError", err)
+	}
+	if _, err := ParseCAAValue("0 issue"); !errors.As(err, &ve) {
+		t.Errorf("ParseCAAValue without data = %v, want a *ValueError", err)
+	}
+}
+
+func TestSameValueCAA(t *testing.T) {
+	if !SameValue(RTypeCAA, `0 issue "letsencrypt.org"`, `0  ISSUE  "letsencrypt.org"`) {
+		t.Error("the blanks and the case of the tag are significant")
+	}
+	if SameValue(RTypeCAA, `0 issue "letsencrypt.org"`, `0 issuewild "letsencrypt.org"`) {
+		t.Error("different tags are the same value")
+	}
+}
@@ -0,0 +1,613 @@
+package dnspod
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//ZoneExportOpt is Optional arg of WriteZone
+type ZoneExportOpt struct {
+	AbsoluteNames bool //Write the owner names as FQDN ("www.example.com.") instead of relative ("www")
+	NoSOA         bool //Do not write the SOA record generated from RecordDomain
+}
+
+//WriteZone writes the records as an RFC 1035 master file.
+//The DNSPod-specific fields (line, weight, status, remark) are written as comments,
+//the disabled records, the records of a line other than "默认" and the URL forwarding
+//records have no equivalent in a plain zone, so they are commented out.
+func WriteZone(w io.Writer, list RecordList, opt ...ZoneExportOpt) error {
+	var o ZoneExportOpt
+	if len(opt) > 0 {
+		o = opt[0]
+	}
+	origin := fqdn(list.Domain.Name)
+	ttl := list.Domain.TTL
+	if ttl == 0 {
+		ttl = 600
+	}
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "; %s exported from dnspod at %s\n", list.Domain.Name, time.Now().Format(timeFormart))
+	fmt.Fprintf(bw, "$ORIGIN %s\n", origin)
+	fmt.Fprintf(bw, "$TTL %d\n", ttl)
+	if !o.NoSOA && len(list.Domain.DnspodNS) > 0 {
+		//dnspod does not expose the SOA, this one only makes the file loadable
+		fmt.Fprintf(bw, "@\tIN\tSOA\t%s hostmaster.%s %s 3600 180 1209600 %d ; generated\n",
+			fqdn(list.Domain.DnspodNS[0]), origin, time.Now().Format("2006010215"), ttl)
+	}
+	for _, r := range list.Records {
+		name := r.Name
+		if o.AbsoluteNames {
+			name = absName(r.Name, origin)
+		}
+		rdata, ok := zoneRData(r)
+		line := fmt.Sprintf("%s\t%d\tIN\t%s\t%s", name, r.TTL, r.Type, rdata)
+		var notes []string
+		if !ok {
+			notes = append(notes, "not a standard record type")
+		}
+		if !r.Enabled {
+			notes = append(notes, "status=disable")
+		}
+		if r.Line != "" && r.Line != string(LineDefault) {
+			notes = append(notes, "line="+r.Line)
+		}
+		if len(notes) > 0 {
+			line = ";" + line
+		}
+		if r.Weight != 0 {
+			notes = append(notes, fmt.Sprintf("weight=%d", r.Weight))
+		}
+		if r.Remark != "" {
+			notes = append(notes, "remark="+strings.ReplaceAll(r.Remark, "\n", " "))
+		}
+		if len(notes) > 0 {
+			line += " ; " + strings.Join(notes, " ")
+		}
+		fmt.Fprintln(bw, line)
+	}
+	return bw.Flush()
+}
+
+//zoneRData returns the RDATA of a record, ok is false when the type has no zone file equivalent
+func zoneRData(r Record) (rdata string, ok bool) {
+	switch RType(r.Type) {
+	case RTypeCNAME, RTypeNS:
+		return fqdn(r.Value), true
+	case RTypeMX:
+		return fmt.Sprintf("%d %s", r.MX, fqdn(r.Value)), true
+	case RTypeSRV:
+		//priority weight port target
+		f := strings.Fields(r.Value)
+		if len(f) == 4 {
+			f[3] = fqdn(f[3])
+		}
+		return strings.Join(f, " "), true
+	case RTypeHTTPS, RTypeSVCB:
+		//priority target params..., "." is the owner name
+		f := strings.Fields(r.Value)
+		if len(f) >= 2 && f[1] != "." {
+			f[1] = fqdn(f[1])
+		}
+		return strings.Join(f, " "), true
+	case RTypeTXT, RTypeSPF:
+		return quoteTXT(r.Value), true
+	case RTypeA, RTypeAAAA, RTypeCAA:
+		return r.Value, true
+	}
+	return r.Value, false
+}
+
+//quoteTXT quotes a TXT value, split into strings of 255 bytes at most
+func quoteTXT(v string) string {
+	//dnspod may keep the quotes of the value, and a long value is several quoted strings
+	return TXTValue{unquoteTXT(v)}.quoted()
+}
+
+func fqdn(name string) string {
+	if strings.HasSuffix(name, ".") {
+		return name
+	}
+	return name + "."
+}
+
+//absName converts a relative owner name to a FQDN
+func absName(name, origin string) string {
+	switch {
+	case name == "@" || name == "":
+		return origin
+	case strings.HasSuffix(name, "."):
+		return name
+	}
+	return name + "." + origin
+}
+
+//Export used to write all records of a domain as an RFC 1035 master file, see WriteZone
+//domain: 		Domain name
+//opt:			The other optional arg
+func (p *RecordAPI) Export(w io.Writer, domain string, opt ...ZoneExportOpt) error {
+	return p.ExportContext(context.Background(), w, domain, opt...)
+}
+
+//ExportContext is like Export but carries ctx for cancellation and deadlines
+func (p *RecordAPI) ExportContext(ctx context.Context, w io.Writer, domain string, opt ...ZoneExportOpt) error {
+	var all RecordList
+	it := p.IterateContext(ctx, domain)
+	for it.Next() {
+		all.Records = append(all.Records, it.Record())
+	}
+	if err := it.Err(); err != nil {
+		return err
+	}
+	all.Domain = it.Domain()
+	if all.Domain.Name == "" {
+		all.Domain.Name = domain
+	}
+	return WriteZone(w, all, opt...)
+}
+
+//SkippedRecord is an entry of a zone file which is not imported
+type SkippedRecord struct {
+	Line   int    //Line number in the zone file
+	Text   string //The entry
+	Reason string
+}
+
+//String interface
+func (s SkippedRecord) String() string {
+	return fmt.Sprintf("line %d: %s: %s", s.Line, s.Reason, s.Text)
+}
+
+//zoneTypes is the record types imported from a zone file
+var zoneTypes = map[RType]bool{
+	RTypeA: true, RTypeAAAA: true, RTypeCNAME: true, RTypeMX: true, RTypeTXT: true, RTypeNS: true,
+	RTypeSRV: true, RTypeCAA: true, RTypeSPF: true, RTypeHTTPS: true, RTypeSVCB: true,
+}
+
+//ParseZone parses an RFC 1035 master file into the records of domain.
+//The SOA, the NS records at the apex (managed by dnspod) and the records of the
+//other types, classes or zones are returned as skipped, so are the values dnspod
+//does not accept, such as a MX priority out of 1-20.
+//domain: 		Domain name, it is also the $ORIGIN until the file sets one
+func ParseZone(r io.Reader, domain string) (records []DesiredRecord, skipped []SkippedRecord, err error) {
+	records, _, skipped, err = parseZone(r, domain)
+	return
+}
+
+//parseZone is ParseZone, sources is the entry of each record, with an empty Reason
+func parseZone(r io.Reader, domain string) (records []DesiredRecord, sources, skipped []SkippedRecord, err error) {
+	zone := strings.ToLower(fqdn(domain))
+	origin := zone
+	var ttl, lastTTL int
+	var owner string
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64<<10), 1<<20)
+	n := 0
+	for {
+		start := n + 1
+		tokens, text, err := zoneEntry(sc, &n)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("dnspod: zone line %d: %w", start, err)
+		}
+		if tokens == nil {
+			break
+		}
+		if len(tokens) == 0 {
+			continue
+		}
+		skip := func(reason string) {
+			skipped = append(skipped, SkippedRecord{Line: start, Text: strings.TrimSpace(text), Reason: reason})
+		}
+		switch strings.ToUpper(tokens[0].text) {
+		case "$ORIGIN":
+			if len(tokens) < 2 {
+				return nil, nil, nil, fmt.Errorf("dnspod: zone line %d: $ORIGIN without a name", start)
+			}
+			origin = strings.ToLower(zoneName(tokens[1].text, origin))
+			continue
+		case "$TTL":
+			if len(tokens) < 2 {
+				return nil, nil, nil, fmt.Errorf("dnspod: zone line %d: $TTL without a value", start)
+			}
+			if ttl, err = zoneTTL(tokens[1].text); err != nil {
+				return nil, nil, nil, fmt.Errorf("dnspod: zone line %d: %w", start, err)
+			}
+			continue
+		}
+		if strings.HasPrefix(tokens[0].text, "$") {
+			skip("unsupported directive")
+			continue
+		}
+
+		//[owner] [ttl] [class] type rdata, an entry starting with a blank uses the previous owner
+		if !tokens[0].blank {
+			owner = strings.ToLower(zoneName(tokens[0].text, origin))
+			tokens = tokens[1:]
+		} else if owner == "" {
+			owner = origin
+		}
+		rttl, class := 0, "IN"
+		for len(tokens) > 0 {
+			t := strings.ToUpper(tokens[0].text)
+			if v, err := zoneTTL(t); err == nil && rttl == 0 {
+				rttl = v
+			} else if t == "IN" || t == "CH" || t == "HS" || t == "CS" {
+				class = t
+			} else {
+				break
+			}
+			tokens = tokens[1:]
+		}
+		if len(tokens) == 0 {
+			return nil, nil, nil, fmt.Errorf("dnspod: zone line %d: no record type", start)
+		}
+		rtype := strings.ToUpper(tokens[0].text)
+		rdata := tokens[1:]
+		if rttl == 0 {
+			//RFC 2308: $TTL, or the TTL of the previous record
+			rttl = ttl
+			if rttl == 0 {
+				rttl = lastTTL
+			}
+		}
+		lastTTL = rttl
+		name, inZone := relName(owner, zone)
+		switch {
+		case class != "IN":
+			skip("unsupported class " + class)
+			continue
+		case rtype == "SOA":
+			skip("SOA is managed by dnspod")
+			continue
+		case rtype == "NS" && name == "@":
+			skip("NS at the apex is managed by dnspod")
+			continue
+		case !zoneTypes[RType(rtype)]:
+			skip("unsupported type " + rtype)
+			continue
+		case !inZone:
+			skip("out of zone " + zone)
+			continue
+		}
+		d := DesiredRecord{Name: name, Type: RType(rtype), TTL: rttl}
+		if d.Value, d.MX, err = zoneValue(d.Type, rdata, origin); err != nil {
+			skip(err.Error())
+			continue
+		}
+		records = append(records, d)
+		sources = append(sources, SkippedRecord{Line: start, Text: strings.TrimSpace(text)})
+	}
+	return records, sources, skipped, nil
+}
+
+//zoneToken is a field of a zone file entry
+type zoneToken struct {
+	text   string
+	quoted bool
+	blank  bool //The first field of an entry starting with a blank
+}
+
+//zoneEntry reads an entry, joining the lines inside parentheses.
+//tokens is nil at the end of the file.
+func zoneEntry(sc *bufio.Scanner, n *int) (tokens []zoneToken, text string, err error) {
+	depth := 0
+	tokens = []zoneToken{}
+	for {
+		if !sc.Scan() {
+			if err = sc.Err(); err == nil && depth > 0 {
+				err = errors.New("unbalanced parentheses")
+			}
+			return nil, "", err
+		}
+		*n++
+		line := sc.Text()
+		first := len(text) == 0
+		text += line + "\n"
+		var cur strings.Builder
+		inToken, quoted, inQuote := false, false, false
+		flush := func() {
+			if inToken {
+				tokens = append(tokens, zoneToken{text: cur.String(), quoted: quoted})
+			}
+			cur.Reset()
+			inToken, quoted = false, false
+		}
+	scan:
+		for i := 0; i < len(line); i++ {
+			c := line[i]
+			switch {
+			case c == '\\' && i+1 < len(line):
+				cur.WriteByte(c)
+				cur.WriteByte(line[i+1])
+				i++
+				inToken = true
+			case inQuote:
+				if c == '"' {
+					inQuote = false
+					flush()
+				} else {
+					cur.WriteByte(c)
+				}
+			case c == '"':
+				flush()
+				inQuote, inToken, quoted = true, true, true
+			case c == ';':
+				break scan
+			case c == '(':
+				flush()
+				depth++
+			case c == ')':
+				flush()
+				if depth--; depth < 0 {
+					return nil, "", errors.New("unbalanced parentheses")
+				}
+			case c == ' ' || c == '\t' || c == '\r':
+				flush()
+			default:
+				cur.WriteByte(c)
+				inToken = true
+			}
+		}
+		if inQuote {
+			return nil, "", errors.New("unterminated quoted string")
+		}
+		flush()
+		if first && len(tokens) > 0 && (line[0] == ' ' || line[0] == '\t') {
+			tokens[0].blank = true
+		}
+		if depth == 0 {
+			return tokens, text, nil
+		}
+	}
+}
+
+//zoneName resolves a domain name of the zone file against origin, the result is a FQDN
+func zoneName(name, origin string) string {
+	switch {
+	case name == "@":
+		return origin
+	case strings.HasSuffix(name, "."):
+		return name
+	}
+	return name + "." + origin
+}
+
+//relName converts a FQDN to a host record of zone, "@" for the apex
+func relName(name, zone string) (string, bool) {
+	if name == zone {
+		return "@", true
+	}
+	if strings.HasSuffix(name, "."+zone) {
+		return strings.TrimSuffix(name, "."+zone), true
+	}
+	return name, false
+}
+
+//zoneTTL parses a TTL, in seconds or with the BIND units (1h30m, 1d, 1w...)
+func zoneTTL(s string) (int, error) {
+	if s == "" {
+		return 0, errors.New("empty TTL")
+	}
+	total, n, digits := 0, 0, false
+	for _, c := range strings.ToLower(s) {
+		if c >= '0' && c <= '9' {
+			n, digits = n*10+int(c-'0'), true
+			continue
+		}
+		unit := map[rune]int{'s': 1, 'm': 60, 'h': 3600, 'd': 86400, 'w': 604800}[c]
+		if unit == 0 || !digits {
+			return 0, fmt.Errorf("invalid TTL %q", s)
+		}
+		total, n, digits = total+n*unit, 0, false
+	}
+	return total + n, nil
+}
+
+//zoneValue converts the RDATA of a record to the dnspod value and MX priority
+func zoneValue(rtype RType, rdata []zoneToken, origin string) (value string, mx int, err error) {
+	want := map[RType]int{RTypeA: 1, RTypeAAAA: 1, RTypeCNAME: 1, RTypeNS: 1, RTypeMX: 2, RTypeSRV: 4, RTypeCAA: 3}[rtype]
+	switch rtype {
+	case RTypeHTTPS, RTypeSVCB:
+		if len(rdata) < 2 {
+			return "", 0, fmt.Errorf("%s needs at least 2 fields, got %d", rtype, len(rdata))
+		}
+		if _, err := strconv.Atoi(rdata[0].text); err != nil {
+			return "", 0, fmt.Errorf("invalid %s priority %q", rtype, rdata[0].text)
+		}
+		f := []string{rdata[0].text, rdata[1].text}
+		if f[1] != "." {
+			f[1] = zoneName(f[1], origin)
+		}
+		for _, t := range rdata[2:] {
+			switch {
+			case t.quoted && strings.HasSuffix(f[len(f)-1], "="):
+				//key="value" is read as "key=" and the quoted value
+				f[len(f)-1] += `"` + t.text + `"`
+			case t.quoted:
+				f = append(f, `"`+t.text+`"`)
+			default:
+				f = append(f, t.text)
+			}
+		}
+		return strings.Join(f, " "), 0, nil
+	case RTypeTXT, RTypeSPF:
+		if len(rdata) == 0 {
+			return "", 0, fmt.Errorf("%s without a value", rtype)
+		}
+		//the strings of a TXT record are joined, dnspod splits long values itself
+		var b strings.Builder
+		for _, t := range rdata {
+			b.WriteString(unescapeZone(t.text))
+		}
+		return b.String(), 0, nil
+	}
+	if len(rdata) != want {
+		return "", 0, fmt.Errorf("%s needs %d fields, got %d", rtype, want, len(rdata))
+	}
+	f := make([]string, len(rdata))
+	for i, t := range rdata {
+		f[i] = t.text
+	}
+	switch rtype {
+	case RTypeA, RTypeAAAA:
+		return f[0], 0, nil
+	case RTypeCNAME, RTypeNS:
+		return zoneName(f[0], origin), 0, nil
+	case RTypeMX:
+		if mx, err = strconv.Atoi(f[0]); err != nil {
+			return "", 0, fmt.Errorf("invalid MX priority %q", f[0])
+		}
+		//dnspod needs a priority from 1 to 20, the others would fail in the middle of the import
+		if mx < 1 || mx > 20 {
+			return "", 0, fmt.Errorf("MX priority %d is out of range 1-20", mx)
+		}
+		return zoneName(f[1], origin), mx, nil
+	case RTypeSRV:
+		for _, s := range f[:3] {
+			if _, err := strconv.Atoi(s); err != nil {
+				return "", 0, fmt.Errorf("invalid SRV field %q", s)
+			}
+		}
+		f[3] = zoneName(f[3], origin)
+		return strings.Join(f, " "), 0, nil
+	}
+	//CAA: flags tag "value"
+	return f[0] + " " + strings.ToLower(f[1]) + ` "` + unescapeZone(f[2]) + `"`, 0, nil
+}
+
+//unescapeZone decodes the \X and \DDD escapes of a zone file string
+func unescapeZone(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i+1 >= len(s) {
+			b.WriteByte(s[i])
+			continue
+		}
+		if i+3 < len(s) && isDigits(s[i+1:i+4]) {
+			v, _ := strconv.Atoi(s[i+1 : i+4])
+			b.WriteByte(byte(v))
+			i += 3
+			continue
+		}
+		b.WriteByte(s[i+1])
+		i++
+	}
+	return b.String()
+}
+
+func isDigits(s string) bool {
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+//ZoneImportOpt is Optional arg of Record.Import
+type ZoneImportOpt struct {
+	DryRun bool      //Only compute what would be created, nothing is changed
+	Output io.Writer //The plan and the skipped records are written to it when set
+	//Replace modifies the live records of the same name, type and line into the imported ones,
+	//by default they are kept and the imported records are added beside them.
+	//In both modes the imported records which can not coexist with a live CNAME are skipped.
+	Replace bool
+}
+
+//ZoneImport is the result of Record.Import
+type ZoneImport struct {
+	Plan    *Plan
+	Skipped []SkippedRecord
+}
+
+//Import used to create the records of an RFC 1035 master file, see ParseZone.
+//The records already existing with the same value are not created again.
+//domain: 		Domain name
+//r:			The zone file
+//opt:			The other optional arg
+func (p *RecordAPI) Import(domain string, r io.Reader, opt ...ZoneImportOpt) (*ZoneImport, error) {
+	return p.ImportContext(context.Background(), domain, r, opt...)
+}
+
+//ImportContext is like Import but carries ctx for cancellation and deadlines
+func (p *RecordAPI) ImportContext(ctx context.Context, domain string, r io.Reader, opt ...ZoneImportOpt) (*ZoneImport, error) {
+	return ImportZone(ctx, p, domain, r, opt...)
+}
+
+//ImportZone is Record.Import on the records served by s
+func ImportZone(ctx context.Context, s RecordService, domain string, r io.Reader, opt ...ZoneImportOpt) (*ZoneImport, error) {
+	var o ZoneImportOpt
+	if len(opt) > 0 {
+		o = opt[0]
+	}
+	records, sources, skipped, err := parseZone(r, domain)
+	if err != nil {
+		return nil, err
+	}
+	plan, live, err := planZone(ctx, s, domain, records, SyncOpt{NoDelete: true})
+	if err != nil {
+		return nil, err
+	}
+	source := map[DesiredRecord]SkippedRecord{}
+	for i, d := range records {
+		if _, ok := source[d.withDefaults()]; !ok {
+			source[d.withDefaults()] = sources[i]
+		}
+	}
+	var changes []Change
+	for _, c := range plan.Changes {
+		if !o.Replace && c.Kind == ChangeModify {
+			//only keep the records to add, the others are already there or would replace a live one
+			if SameValue(c.Desired.Type, c.Current.Value, c.Desired.Value) {
+				continue
+			}
+			c = Change{Kind: ChangeCreate, Desired: c.Desired}
+		}
+		//a record added beside the live ones must not break the CNAME rule
+		if c.Kind == ChangeCreate {
+			if reason := cnameConflict(c.Desired, live); reason != "" {
+				sk := source[c.Desired]
+				sk.Reason = reason
+				skipped = append(skipped, sk)
+				continue
+			}
+		}
+		changes = append(changes, c)
+	}
+	plan.Changes = changes
+	res := &ZoneImport{Plan: plan, Skipped: skipped}
+	if o.Output != nil {
+		fmt.Fprint(o.Output, plan)
+		for _, sk := range skipped {
+			fmt.Fprintf(o.Output, "  ! %s\n", sk)
+		}
+	}
+	if o.DryRun {
+		return res, nil
+	}
+	return res, ApplyPlan(ctx, s, plan)
+}
+
+//cnameConflict returns why d can not be created beside the live records, "" when it can
+func cnameConflict(d DesiredRecord, live []Record) string {
+	for _, r := range live {
+		if !strings.EqualFold(r.Name, d.Name) || r.Line != string(d.Line) {
+			continue
+		}
+		switch {
+		case r.Type == string(RTypeCNAME):
+			return "conflicts with the live CNAME " + r.Value
+		case d.Type == RTypeCNAME:
+			return "CNAME conflicts with the live " + r.Type + " " + r.Value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,114 @@
+package dnspod
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestZoneRoundTrip(t *testing.T) {
+	long := strings.Repeat("a", 300)
+	records := []Record{
+		{Name: "@", Type: "A", Value: "192.0.2.1", TTL: 600},
+		{Name: "www", Type: "AAAA", Value: "2001:db8::1", TTL: 300},
+		{Name: "blog", Type: "CNAME", Value: "www.example.com", TTL: 600},
+		{Name: "@", Type: "MX", Value: "mx.example.com.", MX: 10, TTL: 600},
+		{Name: "txt", Type: "TXT", Value: `v=1; say "hi" \ bye`, TTL: 600},
+		//dnspod keeps a long value as the quoted strings it was created with
+		{Name: "long", Type: "TXT", Value: TXTValue{long}.Value(), TTL: 600},
+		{Name: "_sip._tcp", Type: "SRV", Value: "10 5 5060 sip.example.com.", TTL: 600},
+		{Name: "@", Type: "CAA", Value: `0 issue "letsencrypt.org"`, TTL: 600},
+		{Name: "@", Type: "HTTPS", Value: "1 . alpn=h2", TTL: 600},
+	}
+	for i := range records {
+		records[i].Enabled = true
+	}
+	list := RecordList{
+		Domain: RecordDomain{Name: "example.com", TTL: 600, DnspodNS: []string{"f1g1ns1.dnspod.net"}},
+		Records: append(records,
+			Record{Name: "off", Type: "A", Value: "192.0.2.2", TTL: 600},
+			Record{Name: "tel", Type: "A", Value: "192.0.2.3", TTL: 600, Line: "电信", Enabled: true},
+			Record{Name: "fwd", Type: "显性URL", Value: "https://example.org", TTL: 600, Enabled: true},
+		),
+	}
+	var b bytes.Buffer
+	if err := WriteZone(&b, list); err != nil {
+		t.Fatal(err)
+	}
+	got, skipped, err := ParseZone(&b, "example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(skipped) != 1 || skipped[0].Reason != "SOA is managed by dnspod" {
+		t.Errorf("skipped = %v, want the SOA only", skipped)
+	}
+	//the disabled records, the other lines and URL forwarding are commented out
+	if len(got) != len(records) {
+		t.Fatalf("got %d records, want %d:\n%s", len(got), len(records), b.String())
+	}
+	for i, r := range records {
+		d := got[i]
+		if d.Name != r.Name || string(d.Type) != r.Type || d.TTL != r.TTL || d.MX != r.MX {
+			t.Errorf("record %d = %v, want %s %s", i, d, r.Name, r.Type)
+		}
+		if !SameValue(d.Type, r.Value, d.Value) {
+			t.Errorf("%s %s value = %q, want %q", r.Name, r.Type, d.Value, r.Value)
+		}
+	}
+	if got[5].Value != long {
+		t.Errorf("long TXT = %q, want the joined text", got[5].Value)
+	}
+}
+
+func TestParseZone(t *testing.T) {
+	zone := `$ORIGIN example.com.
+$TTL 1h
+@	IN	SOA	ns1.example.com. hostmaster.example.com. 1 3600 180 1209600 600
+@	IN	NS	ns1.example.com.
+www	IN	A	192.0.2.1
+	IN	A	192.0.2.2 ; same owner
+mail	300	IN	MX	30 mx.example.com.
+mx0	IN	MX	0 .
+mx	IN	MX	5 mx
+txt	IN	TXT	( "part one "
+	"part two" )
+other.org.	IN	A	192.0.2.3
+chaos	CH	TXT	"x"
+$INCLUDE other.zone
+`
+	got, skipped, err := ParseZone(strings.NewReader(zone), "example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []DesiredRecord{
+		{Name: "www", Type: RTypeA, Value: "192.0.2.1", TTL: 3600},
+		{Name: "www", Type: RTypeA, Value: "192.0.2.2", TTL: 3600},
+		{Name: "mx", Type: RTypeMX, Value: "mx.example.com.", MX: 5, TTL: 3600},
+		{Name: "txt", Type: RTypeTXT, Value: "part one part two", TTL: 3600},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("record %d = %v, want %v", i, got[i], want[i])
+		}
+	}
+	reasons := map[int]string{
+		3:  "SOA is managed by dnspod",
+		4:  "NS at the apex is managed by dnspod",
+		7:  "MX priority 30 is out of range 1-20",
+		8:  "MX priority 0 is out of range 1-20",
+		12: "out of zone example.com.",
+		13: "unsupported class CH",
+		14: "unsupported directive",
+	}
+	if len(skipped) != len(reasons) {
+		t.Fatalf("skipped = %v, want %d entries", skipped, len(reasons))
+	}
+	for _, s := range skipped {
+		if reasons[s.Line] != s.Reason {
+			t.Errorf("line %d skipped for %q, want %q", s.Line, s.Reason, reasons[s.Line])
+		}
+	}
+}